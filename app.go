@@ -1,16 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"flag"
 	"fmt"
-	"github.com/MashukeAlam/grails-template/internals"
+	"github.com/MashukeAlam/grails-template/database"
 	"github.com/MashukeAlam/grails-template/helpers"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/MashukeAlam/grails-template/internals"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/template/html/v2"
 	"github.com/joho/godotenv"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"log"
 	"os"
@@ -30,12 +28,6 @@ var (
 	prod = flag.Bool("prod", false, "Enable prefork in Production")
 )
 
-func createDatabase(db *sql.DB, dbName string) error {
-	// Create the database if it doesn't exist
-	_, err := db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbName))
-	return err
-}
-
 func main() {
 	err := godotenv.Load()
 	if err != nil {
@@ -44,70 +36,48 @@ func main() {
 		fmt.Printf("%sENV Loaded.%s\n", Green, Reset)
 	}
 
-	// Database server connection string without database
-	dsn := os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASSWORD") + "@tcp(" + os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT") + ")/"
-	dbNot, err := sql.Open("mysql", dsn)
-	if err != nil {
-		log.Fatal(err)
-	} else {
-		fmt.Printf("%sDatabase server connected.%s\n", Green, Reset)
-	}
-	defer dbNot.Close()
-
-	// Verify the connection
-	if err := dbNot.Ping(); err != nil {
-		log.Fatal(err)
-	}
+	// Parse command-line flags
+	flag.Parse()
 
-	// Create the database if not exist
-	// TODO: send this back at other functions may be in internals
-	err = createDatabase(dbNot, os.Getenv("DB_NAME"))
-	if err != nil {
-		log.Fatalf("Failed to create database: %v", err)
-	} else {
-		fmt.Printf("%sDatabase connected.%s\n", Green, Reset)
+	// Check for migration command before touching the database
+	migrate := false
+	if flag.NArg() > 0 {
+		if flag.Arg(0) != "migrate" {
+			log.Println("For Migration, please type: go run app.go migrate [up | status | down [n] | redo | to <version> | resolve <version>] (or go run ./cmd/grails migrate)")
+			os.Exit(1)
+		}
+		migrate = true
 	}
 
-	// Database connection string with DB
-	dsnWithDB := os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASSWORD") + "@tcp(" + os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT") + ")/" + os.Getenv("DB_NAME") + "?parseTime=true"
-	db, err := sql.Open("mysql", dsnWithDB)
-	if err != nil {
-		log.Fatal(err)
-	} else {
-		fmt.Printf("%sDatabase ready.%s\n", Green, Reset)
+	// gorm derives table names with the same inflections as the generator
+	if err := helpers.LoadProjectConfig(); err != nil {
+		log.Fatalf("%s%v%s", Red, err, Reset)
 	}
-	defer db.Close()
 
-	// DBGORM
-	dbGorm, err := gorm.Open(mysql.Open(dsnWithDB), &gorm.Config{})
+	dbGorm, err := database.Connect()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	} else {
-		fmt.Printf("%sORM Ready.%s\n", Green, Reset)
-		DB = dbGorm
+		log.Fatalf("%s%v%s", Red, err, Reset)
 	}
+	DB = dbGorm
 
-	if len(os.Args) > 1 {
-		// Check for migration command
-		if os.Args[1] == "migrate" {
-			helpers.Migrate(dbGorm) // Run the migrate function with the direction
-			return
-		} else {
-			log.Println("For Migration, please type: go run app.go migrate")
-			os.Exit(1)
+	if migrate {
+		if err := helpers.RunMigrateCommand(dbGorm, flag.Args()[1:]); err != nil {
+			log.Fatalf("%s%v%s", Red, err, Reset)
 		}
+		return
 	}
 
 	// Create a new engine
 	engine := html.New("views", ".html")
-
-	// Parse command-line flags
-	flag.Parse()
+	engine.AddFuncMap(helpers.ViewFuncs)
 
 	// Create fiber app
 	app := fiber.New(fiber.Config{
 		Prefork: *prod, // go run app.go -prod
 		Views:   engine,
+		// Uploads of generated forms are up to 10 MB, and a third more as
+		// the data URLs edit forms send
+		BodyLimit: 16 << 20,
 	})
 
 	app = internals.FiberAppStart(app)
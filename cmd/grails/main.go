@@ -0,0 +1,543 @@
+// Command grails scaffolds, destroys and migrates models without running the
+// dev server. Run it without arguments for its usage.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/MashukeAlam/grails-template/database"
+	"github.com/MashukeAlam/grails-template/helpers"
+	"github.com/MashukeAlam/grails-template/internals"
+	"github.com/gofiber/fiber/v2"
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: grails <command> [arguments]
+
+Commands:
+  generate scaffold <Name> [field:type[:option...] ...] [--belongs-to model] [--has-many model] [--many-to-many model] [--dry-run]
+      types are Go or SQL types such as string, text, int, decimal(10,2),
+      email, image or enum(draft,published); options are null, unique,
+      index, default=value and comment=text, plus accept=image/png,... and
+      max=2MB for file and image fields; quote values holding ":null" and
+      the like, as in default='a:null'
+      association flags take model[:display column], can be repeated and
+      --ref is short for --belongs-to
+  edit scaffold <Name> [field:type[:option...] ...] [--force] [--dry-run]
+      regenerates an existing scaffold for its complete new field list
+  destroy scaffold <Name> [--force] [--dry-run]
+  migrate [up]
+      applies the pending SQL migrations in migrations/
+  migrate status
+  migrate down [n]
+      reverts the last n applied migrations, 1 by default
+  migrate redo
+      reverts the last applied migration and applies it again
+  migrate to <version>
+      applies or reverts migrations until version is the last applied one;
+      0 reverts them all
+  migrate resolve <version>
+      records a migration that failed halfway as it was before, once its
+      changes were undone by hand
+  import [table ...] [--all] [--dry-run]
+      scaffolds models for existing database tables; without tables, lists
+      the tables no model maps to yet
+  doctor [--migration [--dry-run]]
+      compares models.json, the structs in models/ and the database, and
+      with --migration writes a migration reconciling the database with
+      models.json; also available as schema diff
+  routes
+  eject templates [--force]
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate", "g":
+		err = generate(os.Args[2:])
+	case "edit", "e":
+		err = edit(os.Args[2:])
+	case "destroy", "d":
+		err = destroy(os.Args[2:])
+	case "import":
+		err = importTables(os.Args[2:])
+	case "doctor":
+		err = doctor(os.Args[2:])
+	case "schema":
+		if len(os.Args) < 3 || os.Args[2] != "diff" {
+			err = fmt.Errorf("usage: grails schema diff [--migration [--dry-run]]")
+			break
+		}
+		err = doctor(os.Args[3:])
+	case "migrate":
+		err = migrate(os.Args[2:])
+	case "routes":
+		err = routes()
+	case "eject":
+		err = eject(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", os.Args[1], usage)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s%v%s\n", helpers.Red, err, helpers.Reset)
+		os.Exit(1)
+	}
+}
+
+// parseArgs parses flags placed anywhere among the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// associationFlag collects the models given to a repeatable association flag.
+type associationFlag struct {
+	kind         string
+	associations *[]helpers.Association
+}
+
+func (f associationFlag) String() string {
+	return ""
+}
+
+// Set adds the association given as model[:display], display naming the
+// column its records are shown by.
+func (f associationFlag) Set(value string) error {
+	model, display, _ := strings.Cut(value, ":")
+	*f.associations = append(*f.associations, helpers.Association{
+		Kind:    f.kind,
+		Model:   helpers.ToSnakeCase(model),
+		Display: display,
+	})
+	return nil
+}
+
+func generate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	var associations []helpers.Association
+	fs.Var(associationFlag{helpers.BelongsTo, &associations}, "ref", "Model the scaffold belongs to")
+	fs.Var(associationFlag{helpers.BelongsTo, &associations}, "belongs-to", "Model the scaffold belongs to")
+	fs.Var(associationFlag{helpers.HasMany, &associations}, "has-many", "Model holding the ID of the scaffold")
+	fs.Var(associationFlag{helpers.ManyToMany, &associations}, "many-to-many", "Model joined to the scaffold by a join table")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a diff without writing them")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || positional[0] != "scaffold" {
+		return fmt.Errorf("usage: grails generate scaffold <Name> [field:type ...] [--belongs-to model] [--has-many model] [--many-to-many model] [--dry-run]")
+	}
+
+	tableName := helpers.ToSnakeCase(positional[1])
+	fields, err := parseFields(positional[2:])
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		plan, err := helpers.PlanModel(tableName, fields, associations)
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan.Diff())
+		return nil
+	}
+	return helpers.CreateModel(tableName, fields, associations)
+}
+
+// parseField parses a name:type[:option...] field spec, as described in usage.
+func parseField(spec string) (helpers.Field, error) {
+	parts := strings.SplitN(spec, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return helpers.Field{}, fmt.Errorf("invalid field %q, expected name:type[:option...]", spec)
+	}
+	field := helpers.SQLField(parts[0], parts[1])
+	if len(parts) < 3 {
+		return field, nil
+	}
+
+	for _, option := range splitOptions(parts[2]) {
+		key, value, _ := strings.Cut(option, "=")
+		value = unquote(value)
+		switch key {
+		case "null":
+			field.Nullable = true
+		case "unique":
+			field.Unique = true
+		case "index":
+			field.Index = true
+		case "default":
+			field.Default = value
+		case "comment":
+			field.Comment = value
+		case "accept":
+			field.Accept = value
+		case "max":
+			size, err := helpers.ParseSize(value)
+			if err != nil {
+				return helpers.Field{}, fmt.Errorf("invalid max of field %s: %w", field.Name, err)
+			}
+			field.MaxSize = size
+		default:
+			return helpers.Field{}, fmt.Errorf("invalid option %q of field %s, expected null, unique, index, default=value, comment=text, accept=types or max=size", option, field.Name)
+		}
+	}
+	return field, nil
+}
+
+// fieldOptions are the options of field specs taking no value.
+var fieldOptions = map[string]bool{"null": true, "unique": true, "index": true}
+
+// splitOptions splits field options at colons outside quotes that start an
+// option, so default=12:30 keeps its colon.
+func splitOptions(options string) []string {
+	var parts []string
+	var quote rune
+	start := 0
+	for i, r := range options {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case (r == '"' || r == '\'') && i > 0 && options[i-1] == '=':
+			quote = r
+		case r == ':':
+			parts = append(parts, options[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, options[start:])
+
+	var merged []string
+	for _, part := range parts {
+		key, _, valued := strings.Cut(part, "=")
+		startsOption := fieldOptions[part] || valued && identifier(key)
+		if n := len(merged); !startsOption && n > 0 && strings.Contains(merged[n-1], "=") {
+			merged[n-1] += ":" + part
+			continue
+		}
+		merged = append(merged, part)
+	}
+	return merged
+}
+
+// identifier reports whether s could name an option.
+func identifier(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// unquote strips the quotes around an option value.
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// parseFields parses the field specs of a generate or edit command.
+func parseFields(specs []string) ([]helpers.Field, error) {
+	// Specs may name the field types grails.json declares.
+	if err := helpers.LoadProjectConfig(); err != nil {
+		return nil, err
+	}
+	var fields []helpers.Field
+	for _, spec := range specs {
+		field, err := parseField(spec)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func edit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	force := fs.Bool("force", false, "Overwrite files whose hand edits conflict with the regenerated version")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a diff without writing them")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || positional[0] != "scaffold" {
+		return fmt.Errorf("usage: grails edit scaffold <Name> [field:type ...] [--force] [--dry-run]")
+	}
+
+	tableName := helpers.ToSnakeCase(positional[1])
+	fields, err := parseFields(positional[2:])
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		plan, err := helpers.PlanUpdate(tableName, fields, *force)
+		if err != nil {
+			return err
+		}
+		fmt.Print(plan.Diff())
+		if len(plan.Conflicts) > 0 {
+			return fmt.Errorf("conflicts in %s", strings.Join(plan.Conflicts, ", "))
+		}
+		return nil
+	}
+
+	err = helpers.UpdateModel(tableName, fields, *force)
+	var conflict *helpers.MergeConflictError
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("%v\nresolve them by hand, or rerun with --force to overwrite them", err)
+	}
+	return err
+}
+
+func destroy(args []string) error {
+	fs := flag.NewFlagSet("destroy", flag.ContinueOnError)
+	force := fs.Bool("force", false, "Destroy even if generated files were edited by hand")
+	dryRun := fs.Bool("dry-run", false, "Print the changes as a diff without writing them")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 || positional[0] != "scaffold" {
+		return fmt.Errorf("usage: grails destroy scaffold <Name> [--force] [--dry-run]")
+	}
+
+	tableName := helpers.ToSnakeCase(positional[1])
+	if *dryRun {
+		plan, err := helpers.PlanDestroy(tableName, *force)
+		if err == nil {
+			fmt.Print(plan.Diff())
+		}
+		return err
+	}
+
+	err = helpers.DestroyModel(tableName, *force)
+	var edited *helpers.EditedFilesError
+	if errors.As(err, &edited) {
+		return fmt.Errorf("%v\nrerun with --force to destroy anyway", err)
+	}
+	return err
+}
+
+// eject copies the built-in scaffold templates into templates/scaffold, where
+// they take precedence over the built-in ones.
+func eject(args []string) error {
+	fs := flag.NewFlagSet("eject", flag.ContinueOnError)
+	force := fs.Bool("force", false, "Overwrite templates that were already ejected")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 || positional[0] != "templates" {
+		return fmt.Errorf("usage: grails eject templates [--force]")
+	}
+
+	written, err := helpers.EjectTemplates(*force)
+	for _, path := range written {
+		fmt.Printf("%s%sGENERATED%s\t%s\n", helpers.Bold, helpers.Green, helpers.Reset, path)
+	}
+	if err == nil && len(written) == 0 {
+		fmt.Println("Templates were already ejected, rerun with --force to overwrite them")
+	}
+	return err
+}
+
+// connect opens the database configured in .env.
+func connect() (*gorm.DB, error) {
+	if err := godotenv.Load(); err != nil {
+		return nil, fmt.Errorf("error loading .env file: %v", err)
+	}
+	if err := helpers.LoadProjectConfig(); err != nil {
+		return nil, err
+	}
+	return database.Connect()
+}
+
+func migrate(args []string) error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	return helpers.RunMigrateCommand(db, args)
+}
+
+// importTables scaffolds models for existing database tables. Without tables
+// it lists the tables no model maps to yet.
+func importTables(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Import every table that can be imported")
+	dryRun := fs.Bool("dry-run", false, "Print the scaffolds without creating them")
+	tables, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	if *all || len(tables) == 0 {
+		importable, err := helpers.ImportableTables(db)
+		if err != nil {
+			return err
+		}
+		for _, table := range importable {
+			if !*all {
+				if table.Reason != "" {
+					fmt.Printf("%s\t%s%s%s\n", table.Table, helpers.Yellow, table.Reason, helpers.Reset)
+				} else {
+					fmt.Printf("%s\t%s\n", table.Table, table.Model)
+				}
+			} else if table.Reason == "" {
+				tables = append(tables, table.Table)
+			}
+		}
+		if !*all {
+			if len(importable) == 0 {
+				fmt.Println("Every table already has a model")
+			}
+			return nil
+		}
+	}
+
+	if *dryRun {
+		ordered, err := helpers.PlanImport(db, tables)
+		for _, imported := range ordered {
+			fmt.Println(describeImport(imported))
+		}
+		return err
+	}
+	created, err := helpers.ImportTables(db, tables)
+	for _, imported := range created {
+		fmt.Printf("%s%sIMPORTED%s\t%s from %s\n", helpers.Bold, helpers.Green, helpers.Reset, imported.Model, imported.Table)
+	}
+	return err
+}
+
+// describeImport summarizes the scaffold a table is imported as.
+func describeImport(imported helpers.ImportedModel) string {
+	var parts []string
+	for _, field := range imported.Fields {
+		part := field.Name + ":" + field.Type
+		if field.Size > 0 {
+			part += fmt.Sprintf("(%d)", field.Size)
+		} else if field.Precision > 0 {
+			part += fmt.Sprintf("(%d,%d)", field.Precision, field.Scale)
+		}
+		if field.Nullable {
+			part += ":null"
+		}
+		if field.Unique {
+			part += ":unique"
+		}
+		if field.Index {
+			part += ":index"
+		}
+		if field.Default != "" {
+			part += ":default=" + field.Default
+		}
+		parts = append(parts, part)
+	}
+	for _, association := range imported.Associations {
+		parts = append(parts, "--"+strings.ReplaceAll(association.Kind, "_", "-")+" "+association.Model)
+	}
+	return fmt.Sprintf("%s (%s)\t%s", imported.Model, imported.Table, strings.Join(parts, " "))
+}
+
+// doctor reports how models.json, the structs in models/ and the database
+// drifted apart.
+func doctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	migration := fs.Bool("migration", false, "Write a migration bringing the database to what models.json describes")
+	dryRun := fs.Bool("dry-run", false, "Print the migration as a diff without writing it")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 0 {
+		return fmt.Errorf("usage: grails doctor [--migration [--dry-run]]")
+	}
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	if *migration {
+		plan, err := helpers.PlanSchemaReconcile(db)
+		if err != nil {
+			return err
+		}
+		if len(plan.Changes) == 0 {
+			fmt.Println("The database already matches models.json")
+			return nil
+		}
+		if *dryRun {
+			fmt.Print(plan.Diff())
+			return nil
+		}
+		return plan.Apply()
+	}
+
+	report, err := helpers.DiagnoseSchema(db)
+	if err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		fmt.Printf("%s%s%s\t%s\n", helpers.Yellow, issue.Kind, helpers.Reset, issue.Message)
+	}
+	if len(report.Issues) == 0 {
+		fmt.Println("models.json, models/ and the database agree")
+		return nil
+	}
+	if len(report.Reconcile) > 0 {
+		fmt.Println("\nRun grails doctor --migration to write a migration reconciling the database with models.json")
+	}
+	return fmt.Errorf("found %d schema issues", len(report.Issues))
+}
+
+// routes lists the routes SetupRoutes registers. Handlers only capture the
+// database, so no connection is needed to build the route table.
+func routes() error {
+	app := fiber.New()
+	internals.SetupRoutes(app, nil)
+
+	list := app.GetRoutes(true)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Path < list[j].Path
+	})
+	for _, route := range list {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		fmt.Printf("%-8s %s\n", route.Method, route.Path)
+	}
+	return nil
+}
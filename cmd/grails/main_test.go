@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MashukeAlam/grails-template/helpers"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		spec string
+		want helpers.Field
+	}{
+		{"title:string", helpers.Field{Name: "title", Type: "string", Kind: "varchar"}},
+		{"title:string(100):null:unique", helpers.Field{Name: "title", Type: "string", Kind: "varchar", Size: 100, Nullable: true, Unique: true}},
+		{"count:int:index:default=0", helpers.Field{Name: "count", Type: "int", Kind: "int", Index: true, Default: "0"}},
+		{"opens:string:default=09:30:null", helpers.Field{Name: "opens", Type: "string", Kind: "varchar", Default: "09:30", Nullable: true}},
+		{"site:url:comment=see https://example.com", helpers.Field{Name: "site", Type: "string", Kind: "url", Comment: "see https://example.com"}},
+		{"note:string:default='a:null':index", helpers.Field{Name: "note", Type: "string", Kind: "varchar", Default: "a:null", Index: true}},
+		{"quip:string:default=it's", helpers.Field{Name: "quip", Type: "string", Kind: "varchar", Default: "it's"}},
+		{"state:enum(draft,published):default=draft", helpers.Field{Name: "state", Type: "string", Kind: "enum", Values: []string{"draft", "published"}, Default: "draft"}},
+		{"photo:image:accept=image/png,image/gif:max=2MB", helpers.Field{Name: "photo", Type: "string", Kind: "image", Accept: "image/png,image/gif", MaxSize: 2 << 20}},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			field, err := parseField(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(field, test.want) {
+				t.Errorf("got %+v, want %+v", field, test.want)
+			}
+		})
+	}
+}
+
+func TestParseFieldErrors(t *testing.T) {
+	for _, spec := range []string{"title", ":string", "title:", "title:string:bogus", "photo:image:max=lots"} {
+		if _, err := parseField(spec); err == nil {
+			t.Errorf("parseField(%q) succeeded", spec)
+		}
+	}
+}
@@ -1,2 +1,56 @@
 package database
 
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/MashukeAlam/grails-template/helpers"
+	_ "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ServerDSN is the connection string of the database server, without database.
+func ServerDSN() string {
+	return os.Getenv("DB_USER") + ":" + os.Getenv("DB_PASSWORD") + "@tcp(" + os.Getenv("DB_HOST") + ":" + os.Getenv("DB_PORT") + ")/"
+}
+
+// DSN is the connection string of the application database.
+func DSN() string {
+	return ServerDSN() + os.Getenv("DB_NAME") + "?parseTime=true"
+}
+
+func createDatabase(db *sql.DB, dbName string) error {
+	// Create the database if it doesn't exist
+	_, err := db.Exec(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", dbName))
+	return err
+}
+
+// Connect creates the configured database when missing and opens it with gorm.
+// The environment must already be loaded.
+func Connect() (*gorm.DB, error) {
+	server, err := sql.Open("mysql", ServerDSN())
+	if err != nil {
+		return nil, err
+	}
+	defer server.Close()
+
+	// Verify the connection
+	if err := server.Ping(); err != nil {
+		return nil, err
+	}
+	fmt.Printf("%sDatabase server connected.%s\n", helpers.Green, helpers.Reset)
+
+	if err := createDatabase(server, os.Getenv("DB_NAME")); err != nil {
+		return nil, fmt.Errorf("failed to create database: %v", err)
+	}
+	fmt.Printf("%sDatabase ready.%s\n", helpers.Green, helpers.Reset)
+
+	db, err := gorm.Open(mysql.Open(DSN()), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+	fmt.Printf("%sORM Ready.%s\n", helpers.Green, helpers.Reset)
+	return db, nil
+}
@@ -1,16 +1,34 @@
 package handlers
 
 import (
-	"github.com/MashukeAlam/grails-template/helpers"
+	"errors"
 	"fmt"
+
+	"github.com/MashukeAlam/grails-template/helpers"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
+// ScaffoldErrorResponse is the JSON body of every failed scaffold request
+// from the /dev page.
+type ScaffoldErrorResponse struct {
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Step    string   `json:"step,omitempty"`
+	File    string   `json:"file,omitempty"`
+	Files   []string `json:"files,omitempty"`
+	// Fields maps form inputs, such as "tableName" or "fields.0.name", to
+	// what is wrong with them.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
 type ScaffoldData struct {
-	TableName    string          `json:"tableName"`
-	RefTableName string          `json:"refTableName"`
-	Fields       []helpers.Field `json:"fields"`
+	TableName    string                `json:"tableName"`
+	Fields       []helpers.Field       `json:"fields"`
+	Associations []helpers.Association `json:"associations"`
+	// RefTableName is a belongs-to association, as sent before associations
+	// could be listed.
+	RefTableName string `json:"refTableName"`
 }
 
 func GetDevView() fiber.Handler {
@@ -24,49 +42,363 @@ func GetDevView() fiber.Handler {
 		return c.Render("_dev/_dev_index", fiber.Map{
 			"Title":      "Everything Center",
 			"ModelNames": modelNames,
+			"FieldTypes": helpers.FieldTypes(),
 		}, "layouts/main")
 	}
 }
 
-func GetMigration(db *gorm.DB) fiber.Handler {
+// GetMigrations responds with every SQL migration and when it was applied.
+func GetMigrations(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		helpers.Migrate(db)
-		return c.Render("_dev/_dev_index", fiber.Map{
-			"Title": "Everything Center",
-		}, "layouts/main")
+		statuses, err := helpers.MigrationStatuses(db)
+		if err != nil {
+			return migrationError(c, err)
+		}
+		return c.JSON(fiber.Map{"migrations": statuses})
 	}
 }
 
-func ProcessIncomingScaffoldData(db *gorm.DB) fiber.Handler {
+// RunMigrations runs the migrate action named in the path and responds with
+// the migrations it applied and reverted and the new status.
+func RunMigrations(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		var data struct {
-			ScaffoldData ScaffoldData `json:"scaffoldData"`
+			Steps   int    `json:"steps"`
+			Version string `json:"version"`
+		}
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&data); err != nil {
+				return invalidRequest(c)
+			}
 		}
 
-		// Parse the JSON request body
-		if err := c.BodyParser(&data); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Cannot parse JSON",
+		var applied, reverted []helpers.Migration
+		var err error
+		switch c.Params("action") {
+		case "up":
+			applied, err = helpers.MigrateUp(db)
+		case "down":
+			if data.Steps < 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(ScaffoldErrorResponse{
+					Code:    helpers.ErrCodeInvalidRequest,
+					Message: "Steps must be positive",
+					Fields:  map[string]string{"steps": "must be positive"},
+				})
+			}
+			if data.Steps == 0 {
+				data.Steps = 1
+			}
+			reverted, err = helpers.MigrateDown(db, data.Steps)
+		case "redo":
+			var migration *helpers.Migration
+			if migration, err = helpers.MigrateRedo(db); migration != nil {
+				reverted = []helpers.Migration{*migration}
+				if err == nil {
+					applied = reverted
+				}
+			}
+		case "to", "resolve":
+			if data.Version == "" {
+				return c.Status(fiber.StatusBadRequest).JSON(ScaffoldErrorResponse{
+					Code:    helpers.ErrCodeInvalidRequest,
+					Message: "A version is required",
+					Fields:  map[string]string{"version": "is required"},
+				})
+			}
+			if c.Params("action") == "resolve" {
+				err = helpers.MigrateResolve(db, data.Version)
+			} else {
+				applied, reverted, err = helpers.MigrateTo(db, data.Version)
+			}
+		default:
+			return c.Status(fiber.StatusNotFound).JSON(ScaffoldErrorResponse{
+				Code:    helpers.ErrCodeInvalidRequest,
+				Message: "Unknown migrate action " + c.Params("action"),
 			})
 		}
+		if err != nil {
+			return migrationError(c, err)
+		}
 
-		for _, field := range data.ScaffoldData.Fields {
-			fmt.Printf("Field Name: %s, Field Type: %s\n", field.Name, field.Type)
+		statuses, err := helpers.MigrationStatuses(db)
+		if err != nil {
+			return migrationError(c, err)
 		}
+		return c.JSON(fiber.Map{
+			"applied":    applied,
+			"reverted":   reverted,
+			"migrations": statuses,
+		})
+	}
+}
 
-		tableName := data.ScaffoldData.TableName
-		refTableName := data.ScaffoldData.RefTableName
-		fields := data.ScaffoldData.Fields
+// GetSchemaReport responds with how models.json, the structs in models/ and
+// the database drifted apart.
+func GetSchemaReport(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report, err := helpers.DiagnoseSchema(db)
+		if err != nil {
+			return migrationError(c, err)
+		}
+		return c.JSON(report)
+	}
+}
 
-		if refTableName != "" {
-			helpers.CreateModel(tableName, fields, refTableName)
-		} else {
-			helpers.CreateModel(tableName, fields)
+// ReconcileSchema writes a migration bringing the database to what
+// models.json describes, and responds with its files.
+func ReconcileSchema(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		plan, err := helpers.PlanSchemaReconcile(db)
+		if err != nil {
+			return migrationError(c, err)
+		}
+		if err := plan.Apply(); err != nil {
+			return scaffoldError(c, err)
+		}
+		files := []string{}
+		for _, change := range plan.Changes {
+			files = append(files, change.Path)
+		}
+		return c.JSON(fiber.Map{"files": files})
+	}
+}
+
+// GetImportableTables responds with the database tables no model maps to yet.
+func GetImportableTables(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tables, err := helpers.ImportableTables(db)
+		if err != nil {
+			return scaffoldError(c, err)
+		}
+		return c.JSON(fiber.Map{"tables": tables})
+	}
+}
+
+// ImportTables scaffolds the posted database tables and responds with the
+// models created.
+func ImportTables(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var data struct {
+			Tables []string `json:"tables"`
+		}
+		if err := c.BodyParser(&data); err != nil || len(data.Tables) == 0 {
+			return invalidRequest(c)
+		}
+
+		created, err := helpers.ImportTables(db, data.Tables)
+		if err != nil {
+			return scaffoldError(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"message": fmt.Sprintf("Imported %d tables", len(created)),
+			"created": created,
+		})
+	}
+}
+
+// migrationError responds with the ScaffoldErrorResponse describing err.
+func migrationError(c *fiber.Ctx, err error) error {
+	status, code := fiber.StatusInternalServerError, helpers.ErrCodeMigration
+	switch {
+	case errors.Is(err, helpers.ErrUnknownMigration) || errors.Is(err, helpers.ErrNoSQLMigrations):
+		status, code = fiber.StatusBadRequest, helpers.ErrCodeInvalidRequest
+	case errors.Is(err, helpers.ErrDirtyMigration) || errors.Is(err, helpers.ErrMissingMigration):
+		status, code = fiber.StatusConflict, helpers.ErrCodeConflict
+	}
+	return c.Status(status).JSON(ScaffoldErrorResponse{Code: code, Message: err.Error()})
+}
+
+// parseScaffoldData reads the scaffold form posted by the /dev page.
+func parseScaffoldData(c *fiber.Ctx) (ScaffoldData, error) {
+	var data struct {
+		ScaffoldData ScaffoldData `json:"scaffoldData"`
+	}
+
+	// Parse the JSON request body
+	if err := c.BodyParser(&data); err != nil {
+		return ScaffoldData{}, err
+	}
+
+	if data.ScaffoldData.RefTableName != "" {
+		data.ScaffoldData.Associations = append(data.ScaffoldData.Associations, helpers.Association{
+			Kind:  helpers.BelongsTo,
+			Model: data.ScaffoldData.RefTableName,
+		})
+	}
+	return data.ScaffoldData, nil
+}
+
+func PreviewScaffold() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data, err := parseScaffoldData(c)
+		if err != nil {
+			return invalidRequest(c)
+		}
+
+		plan, err := helpers.PlanModel(data.TableName, data.Fields, data.Associations)
+		if err != nil {
+			return scaffoldError(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"diff": plan.Diff(),
+		})
+	}
+}
+
+func ProcessIncomingScaffoldData(db *gorm.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data, err := parseScaffoldData(c)
+		if err != nil {
+			return invalidRequest(c)
+		}
+
+		if err := helpers.CreateModel(data.TableName, data.Fields, data.Associations); err != nil {
+			return scaffoldError(c, err)
 		}
 		return c.JSON(fiber.Map{
 			"message":     "Scaffold created successfully",
 			"action":      "migrate",
-			"actionParam": data.ScaffoldData.TableName,
+			"actionParam": data.TableName,
+		})
+	}
+}
+
+// GetModelSchema responds with the models.json entry of a model, for the
+// /dev page to edit.
+func GetModelSchema() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		models, err := helpers.ReadModelsFromJSON()
+		if err != nil {
+			return scaffoldError(c, err)
+		}
+		schema, ok := models[c.Params("name")]
+		if !ok {
+			return c.Status(fiber.StatusNotFound).JSON(ScaffoldErrorResponse{
+				Code:    helpers.ErrCodeInvalidRequest,
+				Message: "Model not found",
+			})
+		}
+		return c.JSON(fiber.Map{
+			"tableName":    helpers.ToSnakeCase(c.Params("name")),
+			"fields":       helpers.WithFieldKinds(schema.Fields),
+			"associations": schema.Associations,
+		})
+	}
+}
+
+// parseUpdateData reads the edited scaffold posted by the /dev page.
+func parseUpdateData(c *fiber.Ctx) (ScaffoldData, bool, error) {
+	var data struct {
+		ScaffoldData ScaffoldData `json:"scaffoldData"`
+		Force        bool         `json:"force"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return ScaffoldData{}, false, err
+	}
+	return data.ScaffoldData, data.Force, nil
+}
+
+func PreviewUpdate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data, force, err := parseUpdateData(c)
+		if err != nil {
+			return invalidRequest(c)
+		}
+
+		plan, err := helpers.PlanUpdate(data.TableName, data.Fields, force)
+		if err != nil {
+			return scaffoldError(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"diff":      plan.Diff(),
+			"conflicts": plan.Conflicts,
+		})
+	}
+}
+
+func UpdateScaffold() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		data, force, err := parseUpdateData(c)
+		if err != nil {
+			return invalidRequest(c)
+		}
+
+		if err := helpers.UpdateModel(data.TableName, data.Fields, force); err != nil {
+			return scaffoldError(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"message":     "Scaffold updated successfully",
+			"action":      "migrate",
+			"actionParam": data.TableName,
+		})
+	}
+}
+
+func DestroyScaffold() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var data struct {
+			ModelName string `json:"modelName"`
+			Force     bool   `json:"force"`
+		}
+		if err := c.BodyParser(&data); err != nil {
+			return invalidRequest(c)
+		}
+
+		if err := helpers.DestroyModel(helpers.ToSnakeCase(data.ModelName), data.Force); err != nil {
+			return scaffoldError(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"message": data.ModelName + " destroyed",
 		})
 	}
 }
+
+// scaffoldError responds with the ScaffoldErrorResponse describing err.
+func scaffoldError(c *fiber.Ctx, err error) error {
+	body := ScaffoldErrorResponse{
+		Code:    helpers.ErrCodeGenerate,
+		Message: err.Error(),
+	}
+
+	var scaffoldErr *helpers.ScaffoldError
+	if errors.As(err, &scaffoldErr) {
+		body.Code = scaffoldErr.Code
+		body.Message = scaffoldErr.Err.Error()
+		body.Step = scaffoldErr.Step
+		body.File = scaffoldErr.Path
+	}
+	var edited *helpers.EditedFilesError
+	if errors.As(err, &edited) {
+		body.Files = edited.Files
+	}
+	var conflict *helpers.MergeConflictError
+	if errors.As(err, &conflict) {
+		body.Files = conflict.Files
+	}
+	var invalid *helpers.ValidationError
+	if errors.As(err, &invalid) {
+		body.Message = "Some inputs are invalid"
+		body.Fields = invalid.Fields
+	}
+
+	status := fiber.StatusInternalServerError
+	switch body.Code {
+	case helpers.ErrCodeInvalidRequest:
+		status = fiber.StatusBadRequest
+	case helpers.ErrCodeNotFound:
+		status = fiber.StatusNotFound
+	case helpers.ErrCodeConflict, helpers.ErrCodeEditedFiles, helpers.ErrCodeMergeConflict:
+		status = fiber.StatusConflict
+	case helpers.ErrCodeInvalidOutput:
+		status = fiber.StatusUnprocessableEntity
+	}
+	return c.Status(status).JSON(body)
+}
+
+// invalidRequest responds to a request body that could not be parsed.
+func invalidRequest(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(ScaffoldErrorResponse{
+		Code:    helpers.ErrCodeInvalidRequest,
+		Message: "Cannot parse JSON",
+	})
+}
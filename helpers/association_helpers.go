@@ -0,0 +1,369 @@
+package helpers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Kinds of an Association.
+const (
+	BelongsTo  = "belongs_to"
+	HasMany    = "has_many"
+	ManyToMany = "many_to_many"
+)
+
+var associationKinds = map[string]bool{BelongsTo: true, HasMany: true, ManyToMany: true}
+
+// Association is a relationship of a scaffolded model to another model,
+// named by its table name.
+type Association struct {
+	Kind    string `json:"kind"`
+	Model   string `json:"model"`
+	Display string `json:"display,omitempty"`
+}
+
+// displayColumns are preferred, in order, to show records of another model.
+var displayColumns = []string{"Name", "Title", "Label", "Email"}
+
+// displayField returns the struct field of model that shows its records: the
+// one for the column display, or a name-like or string field, or ID.
+func displayField(model, display string) (string, error) {
+	_, content, err := modelFile(&ScaffoldPlan{}, model)
+	if err != nil {
+		return "", err
+	}
+	if content == nil {
+		return "", fmt.Errorf("struct %s not found in the models package", model)
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", content, 0)
+	if err != nil {
+		return "", err
+	}
+
+	fields := map[string]bool{"ID": true}
+	var firstString string
+	for _, field := range findStruct(file, model).Fields.List {
+		for _, ident := range field.Names {
+			fields[ident.Name] = true
+			if typ, ok := field.Type.(*ast.Ident); ok && typ.Name == "string" && firstString == "" {
+				firstString = ident.Name
+			}
+		}
+	}
+
+	if display != "" {
+		name := ToCamelCase(display)
+		if display == "id" {
+			name = "ID"
+		}
+		if !fields[name] {
+			return "", fmt.Errorf("%s has no %s column", model, display)
+		}
+		return name, nil
+	}
+	for _, name := range displayColumns {
+		if fields[name] {
+			return name, nil
+		}
+	}
+	if firstString != "" {
+		return firstString, nil
+	}
+	return "ID", nil
+}
+
+// associationsOf returns the associations of kind.
+func associationsOf(associations []Association, kind string) []ScaffoldNames {
+	var names []ScaffoldNames
+	for _, association := range associations {
+		if association.Kind == kind {
+			names = append(names, NewScaffoldNames(association.Model))
+		}
+	}
+	return names
+}
+
+// joinNames returns the names of the join model of a many-to-many
+// association between names and other, such as PostTag for "post_tags".
+func joinNames(names, other ScaffoldNames) ScaffoldNames {
+	return NewScaffoldNames(names.Table + "_" + other.Table)
+}
+
+// backReference is a field a scaffold adds to the struct of another model,
+// so both sides of an association can be navigated.
+type backReference struct {
+	Model string // the struct the field is added to
+	Field string
+	Code  string // the field declaration
+}
+
+// backReferences lists the fields the associations of names add to other
+// models.
+func backReferences(names ScaffoldNames, associations []Association, tagKey func(string) string) []backReference {
+	var refs []backReference
+	for _, association := range associations {
+		other := NewScaffoldNames(association.Model)
+		ref := backReference{Model: other.Model}
+		switch association.Kind {
+		case BelongsTo:
+			ref.Field = names.ModelPlural
+			ref.Code = fmt.Sprintf("%s []%s `json:%q`", names.ModelPlural, names.Model, tagKey(names.ViewDir)+",omitempty")
+		case HasMany:
+			key := tagKey(names.Table + "_id")
+			ref.Field = names.Model + "ID"
+			ref.Code = fmt.Sprintf("%sID uint `json:%q form:%q`", names.Model, key, key)
+		case ManyToMany:
+			ref.Field = names.ModelPlural
+			ref.Code = fmt.Sprintf("%s []%s `json:%q gorm:%q`", names.ModelPlural, names.Model,
+				tagKey(names.ViewDir)+",omitempty", "many2many:"+joinNames(names, other).ViewDir)
+		default:
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// findStruct returns the struct type declared as name in file, or nil.
+func findStruct(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != name {
+				continue
+			}
+			if st, ok := typeSpec.Type.(*ast.StructType); ok {
+				return st
+			}
+		}
+	}
+	return nil
+}
+
+// structHasField reports whether st declares a field called name.
+func structHasField(st *ast.StructType, name string) bool {
+	for _, field := range st.Fields.List {
+		for _, ident := range field.Names {
+			if ident.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// insertStructField appends the field declaration code to the struct
+// structName in src and returns the formatted result.
+func insertStructField(src []byte, structName, fieldName, code string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	st := findStruct(file, structName)
+	if st == nil {
+		return nil, fmt.Errorf("struct %s not found", structName)
+	}
+	if structHasField(st, fieldName) {
+		return nil, fmt.Errorf("%s already has a field %s", structName, fieldName)
+	}
+
+	src = insertAt(src, fset.Position(st.Fields.Closing).Offset, "\t"+code+"\n")
+	return format.Source(src)
+}
+
+// removeStructField deletes the field fieldName from the struct structName in
+// src. It returns nil when there is no such field.
+func removeStructField(src []byte, structName, fieldName string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	st := findStruct(file, structName)
+	if st == nil {
+		return nil, nil
+	}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 1 && field.Names[0].Name == fieldName {
+			span := lineSpan{fset.Position(field.Pos()).Line, fset.Position(field.End()).Line}
+			return format.Source(removeLines(src, []lineSpan{span}))
+		}
+	}
+	return nil, nil
+}
+
+// modelFiles lists the Go files of the models package once plan is applied.
+func modelFiles(plan *ScaffoldPlan) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join("models", "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, path := range paths {
+		seen[path] = true
+	}
+	for _, change := range plan.Changes {
+		if filepath.Dir(change.Path) == "models" && strings.HasSuffix(change.Path, ".go") && !seen[change.Path] {
+			paths = append(paths, change.Path)
+		}
+	}
+	sort.Strings(paths)
+
+	var files []string
+	for _, path := range paths {
+		if content, err := plan.current(path); err != nil {
+			return nil, err
+		} else if content != nil {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// modelFile returns the file declaring the struct model, or "" if none does.
+// The file a scaffold would generate is looked at first.
+func modelFile(plan *ScaffoldPlan, model string) (string, []byte, error) {
+	paths, err := modelFiles(plan)
+	if err != nil {
+		return "", nil, err
+	}
+	generated := NewScaffoldNames(ToSnakeCase(model)).ModelFile()
+	sort.SliceStable(paths, func(i, j int) bool {
+		return paths[i] == generated && paths[j] != generated
+	})
+
+	for _, path := range paths {
+		content, err := plan.current(path)
+		if err != nil {
+			return "", nil, err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, content, 0)
+		if err != nil {
+			return "", nil, err
+		}
+		if findStruct(file, model) != nil {
+			return path, content, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// planBackReferences adds back references to the other models.
+func planBackReferences(plan *ScaffoldPlan, refs []backReference) error {
+	for _, ref := range refs {
+		path, content, err := modelFile(plan, ref.Model)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			return fmt.Errorf("struct %s not found in the models package", ref.Model)
+		}
+		newContent, err := insertStructField(content, ref.Model, ref.Field, ref.Code)
+		if err != nil {
+			return &ScaffoldError{Code: ErrCodeConflict, Step: StepModel, Path: path, Err: err}
+		}
+		if err := plan.write(path, newContent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planBackReferencesRemoval removes back references from the other models,
+// skipping the ones that no longer exist.
+func planBackReferencesRemoval(plan *ScaffoldPlan, refs []backReference) error {
+	for _, ref := range refs {
+		path, content, err := modelFile(plan, ref.Model)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			continue
+		}
+		newContent, err := removeStructField(content, ref.Model, ref.Field)
+		if err != nil {
+			return err
+		}
+		if newContent == nil {
+			continue
+		}
+		if err := plan.write(path, newContent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planAssociations adds back references to the other models and generates
+// the join models of many-to-many associations.
+func planAssociations(plan *ScaffoldPlan, names ScaffoldNames, associations []Association) error {
+	config, err := ReadProjectConfig()
+	if err != nil {
+		return stepError(ErrCodeInvalidRequest, StepCheck, configFilePath, err)
+	}
+	if err := planBackReferences(plan, backReferences(names, associations, tagCases[config.TagCase])); err != nil {
+		return stepError(ErrCodeGenerate, StepModel, "models", fmt.Errorf("failed to add associations: %w", err))
+	}
+
+	for _, other := range associationsOf(associations, ManyToMany) {
+		join := joinNames(names, other)
+		if path, _, err := modelFile(plan, join.Model); err != nil || path != "" {
+			if err == nil {
+				err = fmt.Errorf("model %s already exists", join.Model)
+			}
+			return stepError(ErrCodeConflict, StepModel, join.ModelFile(), err)
+		}
+
+		data, err := newJoinTemplateData(names, other)
+		if err != nil {
+			return stepError(ErrCodeGenerate, StepCheck, "go.mod", err)
+		}
+		content, err := renderGoTemplate("join.go.tmpl", data)
+		if err == nil {
+			err = plan.generate(join.ModelFile(), content)
+		}
+		if err != nil {
+			return stepError(ErrCodeGenerate, StepModel, join.ModelFile(), err)
+		}
+	}
+	return nil
+}
+
+// joinFiles lists the join models generated for the associations of names.
+func joinFiles(names ScaffoldNames, associations []Association) []string {
+	var files []string
+	for _, other := range associationsOf(associations, ManyToMany) {
+		files = append(files, joinNames(names, other).ModelFile())
+	}
+	return files
+}
+
+// planAssociationsRemoval undoes planAssociations.
+func planAssociationsRemoval(plan *ScaffoldPlan, names ScaffoldNames, associations []Association) error {
+	config, err := ReadProjectConfig()
+	if err != nil {
+		return stepError(ErrCodeInvalidRequest, StepCheck, configFilePath, err)
+	}
+	if err := planBackReferencesRemoval(plan, backReferences(names, associations, tagCases[config.TagCase])); err != nil {
+		return stepError(ErrCodeGenerate, StepModel, "models", fmt.Errorf("failed to remove associations: %w", err))
+	}
+
+	for _, other := range associationsOf(associations, ManyToMany) {
+		join := joinNames(names, other)
+		if err := plan.remove(join.ModelFile()); err != nil {
+			return stepError(ErrCodeGenerate, StepModel, join.ModelFile(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModels writes the structs of an existing project, keyed by file name.
+func writeModels(t *testing.T, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll("models", 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join("models", name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// planPostWithAssociations plans a post scaffold belonging to users, having
+// many comments and many tags.
+func planPostWithAssociations(t *testing.T) *ScaffoldPlan {
+	t.Helper()
+	chdirTemp(t)
+	writeGoMod(t)
+	writeModels(t, map[string]string{
+		"user.go":    "package models\n\ntype User struct {\n\tName string\n}\n",
+		"comment.go": "package models\n\ntype Comment struct {\n\tBody string\n}\n",
+		"tag.go":     "package models\n\ntype Tag struct {\n\tLabel string\n}\n",
+	})
+	plan, err := PlanModel("post", []Field{{Name: "title", Type: "string"}}, []Association{
+		{Kind: BelongsTo, Model: "users"},
+		{Kind: HasMany, Model: "comment"},
+		{Kind: ManyToMany, Model: "tag"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+// checkContains fails t for each of wants that the content planned for path
+// lacks.
+func checkContains(t *testing.T, plan *ScaffoldPlan, path string, wants ...string) {
+	t.Helper()
+	content, err := plan.current(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range wants {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("%s lacks %q:\n%s", path, want, content)
+		}
+	}
+}
+
+func TestPlanModelAssociations(t *testing.T) {
+	plan := planPostWithAssociations(t)
+
+	checkContains(t, plan, "models/post.go",
+		"UserID   uint      `json:\"user_id\" form:\"user_id\"`",
+		"User     User      `gorm:\"foreignKey:UserID;references:ID\"`",
+		"Comments []Comment `json:\"comments,omitempty\"`",
+		"Tags     []Tag     `json:\"tags,omitempty\" gorm:\"many2many:post_tags\"`",
+	)
+	checkContains(t, plan, "models/user.go", "Posts []Post `json:\"posts,omitempty\"`")
+	checkContains(t, plan, "models/comment.go", "PostID uint `json:\"post_id\" form:\"post_id\"`")
+	checkContains(t, plan, "models/tag.go", "Posts []Post `json:\"posts,omitempty\" gorm:\"many2many:post_tags\"`")
+	checkContains(t, plan, "models/post_tag.go",
+		"type PostTag struct",
+		"PostID uint `json:\"post_id\" gorm:\"primaryKey\"`",
+		"TagID  uint `json:\"tag_id\" gorm:\"primaryKey\"`",
+	)
+	for _, change := range plan.Changes {
+		if strings.HasSuffix(change.Path, "_create_posts.up.sql") {
+			for _, want := range []string{
+				"CREATE TABLE IF NOT EXISTS `post_tags`",
+				"ALTER TABLE `comments` ADD COLUMN `post_id` bigint unsigned NULL;",
+				"FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`)",
+				"FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)",
+			} {
+				if !strings.Contains(string(change.After), want) {
+					t.Errorf("%s lacks %q:\n%s", change.Path, want, change.After)
+				}
+			}
+		}
+	}
+
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshotScaffold(plan.Model, plan.Generated); err != nil {
+		t.Fatal(err)
+	}
+	destroy, err := PlanDestroy("post", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"user.go":    "package models\n\ntype User struct {\n\tName string\n}\n",
+		"comment.go": "package models\n\ntype Comment struct {\n\tBody string\n}\n",
+		"tag.go":     "package models\n\ntype Tag struct {\n\tLabel string\n}\n",
+	} {
+		if got, _ := destroy.current(filepath.Join("models", name)); string(got) != want {
+			t.Errorf("destroying the scaffold left models/%s as:\n%s", name, got)
+		}
+	}
+	if got, _ := destroy.current("models/post_tag.go"); got != nil {
+		t.Errorf("destroying the scaffold kept the join model:\n%s", got)
+	}
+}
+
+func TestPlanModelRefusesExistingJoinModel(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	writeModels(t, map[string]string{
+		"tag.go":      "package models\n\ntype Tag struct {\n\tLabel string\n}\n",
+		"post_tag.go": "package models\n\ntype PostTag struct {\n\tPostID uint\n}\n",
+	})
+	if _, err := PlanModel("post", nil, []Association{{Kind: ManyToMany, Model: "tag"}}); err == nil {
+		t.Error("planned a join model that already exists")
+	}
+}
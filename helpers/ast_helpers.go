@@ -0,0 +1,292 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ModulePath reads the module path declared in go.mod.
+func ModulePath() (string, error) {
+	content, err := os.ReadFile("go.mod")
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module") {
+			path := strings.TrimSpace(strings.TrimPrefix(line, "module"))
+			if unquoted, err := strconv.Unquote(path); err == nil {
+				path = unquoted
+			}
+			if path != "" {
+				return path, nil
+			}
+		}
+	}
+	return "", errors.New("no module directive found in go.mod")
+}
+
+// findFuncDecl returns the top level function called name, or nil.
+func findFuncDecl(file *ast.File, name string) *ast.FuncDecl {
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == name {
+			return fn
+		}
+	}
+	return nil
+}
+
+// paramNameOfType returns the name of the first parameter of fn whose type is
+// *pkg.Name, e.g. *fiber.App.
+func paramNameOfType(fn *ast.FuncDecl, pkg, name string) string {
+	for _, param := range fn.Type.Params.List {
+		star, ok := param.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := star.X.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != name {
+			continue
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == pkg && len(param.Names) > 0 {
+			return param.Names[0].Name
+		}
+	}
+	return ""
+}
+
+// appendStatements appends the statements of code, comments included, to the
+// body of the function name in src and returns the formatted result. Blank
+// space shaped like code is reserved before the closing brace first, so the
+// statements get positions there and the printer keeps their lines and
+// comments, which it cannot place for nodes without positions.
+func appendStatements(src []byte, name, code string) ([]byte, error) {
+	const wrapper = "package p\n\nfunc _() {\n"
+	codeFset := token.NewFileSet()
+	codeFile, err := parser.ParseFile(codeFset, "", wrapper+code+"\n}\n", parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	stmts := codeFile.Decls[0].(*ast.FuncDecl).Body.List
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	fn := findFuncDecl(file, name)
+	if fn == nil || fn.Body == nil {
+		return nil, fmt.Errorf("no %s function", name)
+	}
+	offset := fset.Position(fn.Body.Rbrace).Offset
+
+	room := []byte(code)
+	for i, b := range room {
+		if b != '\n' {
+			room[i] = ' '
+		}
+	}
+	fset = token.NewFileSet()
+	file, err = parser.ParseFile(fset, "", insertAt(src, offset, string(room)), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	fn = findFuncDecl(file, name)
+
+	tokFile, codeTokFile := fset.File(file.Pos()), codeFset.File(codeFile.Pos())
+	move := func(pos token.Pos) token.Pos {
+		return tokFile.Pos(offset + codeTokFile.Offset(pos) - len(wrapper))
+	}
+	for _, stmt := range stmts {
+		movePositions(stmt, move)
+	}
+	for _, group := range codeFile.Comments {
+		movePositions(group, move)
+		file.Comments = append(file.Comments, group)
+	}
+	sort.Slice(file.Comments, func(i, j int) bool {
+		return file.Comments[i].Pos() < file.Comments[j].Pos()
+	})
+	fn.Body.List = append(fn.Body.List, stmts...)
+
+	var out bytes.Buffer
+	if err := format.Node(&out, fset, file); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+var posType = reflect.TypeOf(token.NoPos)
+
+// movePositions replaces every valid position held by node and its children.
+func movePositions(node ast.Node, move func(token.Pos) token.Pos) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		v := reflect.ValueOf(n)
+		if n == nil || v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return true
+		}
+		v = v.Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Field(i); field.Type() == posType && token.Pos(field.Int()).IsValid() {
+				field.SetInt(int64(move(token.Pos(field.Int()))))
+			}
+		}
+		return true
+	})
+}
+
+// insertAt splices code into src at offset.
+func insertAt(src []byte, offset int, code string) []byte {
+	out := make([]byte, 0, len(src)+len(code))
+	out = append(out, src[:offset]...)
+	out = append(out, code...)
+	return append(out, src[offset:]...)
+}
+
+// ensureImports adds every missing import path to the Go source in src.
+func ensureImports(src []byte, paths ...string) ([]byte, error) {
+	for _, path := range paths {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+
+		imported := false
+		for _, spec := range file.Imports {
+			if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+				imported = true
+				break
+			}
+		}
+		if imported {
+			continue
+		}
+
+		var block *ast.GenDecl
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				block = gen
+				break
+			}
+		}
+
+		switch {
+		case block != nil && block.Lparen.IsValid() && isStdImport(path):
+			// Standard library packages go first, in a group of their own.
+			spec := fmt.Sprintf("\n\t%q", path)
+			if first, ok := block.Specs[0].(*ast.ImportSpec); ok && !isStdImport(strings.Trim(first.Path.Value, `"`)) {
+				spec += "\n"
+			}
+			src = insertAt(src, fset.Position(block.Lparen).Offset+1, spec)
+		case block != nil && block.Lparen.IsValid():
+			offset := fset.Position(block.Rparen).Offset
+			src = insertAt(src, offset, fmt.Sprintf("\t%q\n", path))
+		case block != nil && isStdImport(path):
+			end := fset.Position(block.End()).Offset
+			src = insertAt(src, end, "\n)")
+			src = insertAt(src, fset.Position(block.Specs[0].Pos()).Offset, fmt.Sprintf("(\n\t%q\n\n\t", path))
+		case block != nil:
+			// Turn `import "x"` into a parenthesized block holding both paths.
+			end := fset.Position(block.End()).Offset
+			src = insertAt(src, end, fmt.Sprintf("\n\t%q\n)", path))
+			src = insertAt(src, fset.Position(block.Specs[0].Pos()).Offset, "(\n\t")
+		default:
+			offset := fset.Position(file.Name.End()).Offset
+			src = insertAt(src, offset, fmt.Sprintf("\n\nimport %q", path))
+		}
+	}
+	return format.Source(src)
+}
+
+// isStdImport reports whether path is a standard library package, whose
+// first element has no dot.
+func isStdImport(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// lineSpan is an inclusive range of 1-based line numbers.
+type lineSpan struct {
+	from, to int
+}
+
+// removeLines deletes every line covered by spans from src.
+func removeLines(src []byte, spans []lineSpan) []byte {
+	lines := strings.SplitAfter(string(src), "\n")
+	var out strings.Builder
+	for i, line := range lines {
+		removed := false
+		for _, span := range spans {
+			if i+1 >= span.from && i+1 <= span.to {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			out.WriteString(line)
+		}
+	}
+	return []byte(out.String())
+}
+
+// leadingComment returns the comment group that ends on the line right above
+// node, if any.
+func leadingComment(fset *token.FileSet, file *ast.File, node ast.Node) *ast.CommentGroup {
+	line := fset.Position(node.Pos()).Line
+	for _, group := range file.Comments {
+		if fset.Position(group.End()).Line == line-1 {
+			return group
+		}
+	}
+	return nil
+}
+
+// removeImportIfUnused drops the import of path from src when nothing in the
+// file refers to its package name anymore.
+func removeImportIfUnused(src []byte, path, name string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	used := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == name {
+				used = true
+			}
+		}
+		return !used
+	})
+	if used {
+		return src, nil
+	}
+
+	for _, spec := range file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			line := fset.Position(spec.Pos()).Line
+			return format.Source(removeLines(src, []lineSpan{{line, line}}))
+		}
+	}
+	return src, nil
+}
+
+// isBlankLine reports whether the 1-based line of src holds only whitespace.
+func isBlankLine(src []byte, line int) bool {
+	lines := strings.Split(string(src), "\n")
+	return line >= 1 && line <= len(lines) && strings.TrimSpace(lines[line-1]) == ""
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// configFilePath holds per-project generator settings, next to models.json.
+const configFilePath = "grails.json"
+
+// ProjectConfig is the content of grails.json. Every setting is optional.
+type ProjectConfig struct {
+	Inflections InflectionConfig `json:"inflections"`
+	// TagCase is the naming convention of the json and form tags of generated
+	// models: "snake" (the default), "camel", "pascal" or "kebab".
+	TagCase string `json:"tagCase,omitempty"`
+	// FieldTypes are field types of the project, beside the builtin ones.
+	FieldTypes []FieldType `json:"fieldTypes,omitempty"`
+}
+
+// InflectionConfig lists words the English rules get wrong for this project.
+type InflectionConfig struct {
+	// Irregular maps a singular to its plural, such as "cactus": "cacti".
+	Irregular map[string]string `json:"irregular,omitempty"`
+	// Uncountable words are the same in singular and plural, such as "equipment".
+	Uncountable []string `json:"uncountable,omitempty"`
+}
+
+// ReadProjectConfig reads grails.json, returning the defaults when it does
+// not exist.
+func ReadProjectConfig() (ProjectConfig, error) {
+	var config ProjectConfig
+	content, err := os.ReadFile(configFilePath)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("invalid %s: %w", configFilePath, err)
+	}
+	if _, ok := tagCases[config.TagCase]; !ok {
+		return config, fmt.Errorf("invalid tagCase %q in %s, expected snake, camel, pascal or kebab", config.TagCase, configFilePath)
+	}
+	return config, nil
+}
+
+// LoadProjectConfig applies grails.json: the inflections the generator shares
+// with gorm and the field types the project declares.
+func LoadProjectConfig() error {
+	if err := LoadInflections(); err != nil {
+		return err
+	}
+	config, err := ReadProjectConfig()
+	if err != nil {
+		return err
+	}
+	return loadConfigFieldTypes(config.FieldTypes)
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Codes of a ScaffoldError, shared with the /dev page.
+const (
+	ErrCodeInvalidRequest = "invalid_request"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeConflict       = "conflict"
+	ErrCodeEditedFiles    = "edited_files"
+	ErrCodeMergeConflict  = "merge_conflict"
+	ErrCodeGenerate       = "generate_failed"
+	ErrCodeInvalidOutput  = "invalid_output"
+	ErrCodeWrite          = "write_failed"
+	// ErrCodeMigration reports a migration that failed to run.
+	ErrCodeMigration = "migration_failed"
+)
+
+// Steps of a scaffold command a ScaffoldError can point at.
+const (
+	StepCheck      = "check"
+	StepModel      = "model"
+	StepMigration  = "migration"
+	StepHandler    = "handler"
+	StepRoutes     = "routes"
+	StepViews      = "views"
+	StepModelsJSON = "models.json"
+	StepMerge      = "merge"
+	StepValidate   = "validate"
+	StepWrite      = "write"
+	StepSnapshot   = "snapshot"
+)
+
+// ScaffoldError is returned by every scaffold command. It names the step that
+// failed and, when known, the file it was working on.
+type ScaffoldError struct {
+	Code string
+	Step string
+	Path string
+	Err  error
+}
+
+func (e *ScaffoldError) Error() string {
+	if e.Path != "" {
+		return fmt.Sprintf("%s %s: %v", e.Step, e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *ScaffoldError) Unwrap() error {
+	return e.Err
+}
+
+// stepError wraps err as a failure of step on path. Errors that already are
+// a *ScaffoldError keep their own, more precise, details.
+func stepError(code, step, path string, err error) error {
+	var scaffoldErr *ScaffoldError
+	if errors.As(err, &scaffoldErr) {
+		return err
+	}
+	return &ScaffoldError{Code: code, Step: step, Path: path, Err: err}
+}
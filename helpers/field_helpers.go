@@ -0,0 +1,305 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// gormTag renders the column options of field as gorm tag settings, such as
+// "size:100;not null;unique".
+func gormTag(field Field) string {
+	var settings []string
+	if field.Size > 0 {
+		settings = append(settings, fmt.Sprintf("size:%d", field.Size))
+	}
+	fieldType := fieldTypeOf(field)
+	if field.SQLType != "" || fieldType.SQLType != "" || fieldType.Name == "enum" || strings.TrimPrefix(field.Type, "*") == "decimal.Decimal" {
+		// gorm would store decimals, which it only knows as valuers, as text,
+		// and enums as any other string.
+		settings = append(settings, "type:"+sqlDataType(field))
+	} else {
+		if field.Precision > 0 {
+			settings = append(settings, fmt.Sprintf("precision:%d", field.Precision))
+		}
+		if field.Scale > 0 {
+			settings = append(settings, fmt.Sprintf("scale:%d", field.Scale))
+		}
+	}
+	if !isNullable(field) {
+		settings = append(settings, "not null")
+	}
+	if field.Unique {
+		settings = append(settings, "unique")
+	}
+	if field.Index {
+		settings = append(settings, "index")
+	}
+	if field.Default != "" {
+		settings = append(settings, "default:"+field.Default)
+	}
+	if field.Comment != "" {
+		settings = append(settings, "comment:"+field.Comment)
+	}
+	if fieldType.Gorm != "" {
+		settings = append(settings, fieldType.Gorm)
+	}
+	return strings.Join(settings, ";")
+}
+
+// fieldTag renders the struct tag of a field stored under key, backquotes
+// included.
+func fieldTag(field Field, key string) string {
+	formKey := key
+	if parse := fieldTypeOf(field).Parse; parse == "file" || parse == "json" || parse == "upload" {
+		formKey = "-"
+	}
+	tag := fmt.Sprintf("json:%q form:%q", key, formKey)
+	if settings := gormTag(field); settings != "" {
+		tag += fmt.Sprintf(" gorm:%q", settings)
+	}
+	return "`" + tag + "`"
+}
+
+// typePackages maps the package a field type is qualified with to its import
+// path.
+var typePackages = map[string]string{
+	"time":      "time",
+	"decimal":   "github.com/shopspring/decimal",
+	"datatypes": "gorm.io/datatypes",
+}
+
+// typeModules are the modules outside the standard library providing field
+// types, with the version go.mod requires once a scaffold uses one.
+var typeModules = map[string]string{
+	"github.com/shopspring/decimal": "v1.4.0",
+	"gorm.io/datatypes":             "v1.2.0",
+}
+
+// fieldImports returns the import paths of the packages the types of fields
+// come from, sorted.
+func fieldImports(fields []Field) []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, field := range fields {
+		goType := strings.TrimLeft(field.Type, "*[]")
+		dot := strings.Index(goType, ".")
+		if dot < 0 {
+			continue
+		}
+		if path, ok := typePackages[goType[:dot]]; ok && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// planModuleRequirements plans go.mod to require the modules providing the
+// types of fields that it does not require yet, next to the other direct
+// requirements, and go.sum to hold their checksums.
+func planModuleRequirements(plan *ScaffoldPlan, fields []Field) error {
+	content, err := plan.current("go.mod")
+	if err != nil {
+		return err
+	}
+	file, err := modfile.Parse("go.mod", content, nil)
+	if err != nil {
+		return err
+	}
+
+	required := map[string]bool{}
+	requires := make([]*modfile.Require, 0, len(file.Require))
+	for _, req := range file.Require {
+		required[req.Mod.Path] = true
+		requires = append(requires, req)
+	}
+	var missing []string
+	for _, path := range fieldImports(fields) {
+		if version, ok := typeModules[path]; ok && !required[path] {
+			requires = append(requires, &modfile.Require{Mod: module.Version{Path: path, Version: version}})
+			missing = append(missing, path+"@"+version)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	file.SetRequireSeparateIndirect(requires)
+	file.SortBlocks()
+	file.Cleanup()
+	if content, err = file.Format(); err != nil {
+		return err
+	}
+
+	sums, err := plan.current("go.sum")
+	if err != nil {
+		return err
+	}
+	content, sums, err = goGet(content, sums, missing)
+	if err != nil {
+		return err
+	}
+	if err := plan.write("go.mod", content); err != nil {
+		return err
+	}
+	return plan.write("go.sum", sums)
+}
+
+// goGet runs go get for modules on a copy of goMod and goSum, which leaves
+// the project alone until the plan is applied, and returns both as go get
+// left them, with the checksums of the modules and their requirements.
+func goGet(goMod, goSum []byte, modules []string) ([]byte, []byte, error) {
+	dir, err := os.MkdirTemp("", "grails-go-get-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), goMod, 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0644); err != nil {
+		return nil, nil, err
+	}
+	cmd := exec.Command("go", append([]string{"get"}, modules...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, nil, fmt.Errorf("go get %s: %v\n%s", strings.Join(modules, " "), err, output)
+	}
+
+	if goMod, err = os.ReadFile(filepath.Join(dir, "go.mod")); err != nil {
+		return nil, nil, err
+	}
+	if goSum, err = os.ReadFile(filepath.Join(dir, "go.sum")); err != nil {
+		return nil, nil, err
+	}
+	return goMod, goSum, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isNullable reports whether the column of field accepts NULL, as pointer
+// types store nil as NULL.
+func isNullable(field Field) bool {
+	return field.Nullable || strings.HasPrefix(field.Type, "*")
+}
+
+func isTextType(goType string) bool {
+	return goType == "string" || goType == "[]byte"
+}
+
+func isIntegerType(goType string) bool {
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+func isDecimalType(goType string) bool {
+	return goType == "float32" || goType == "float64" || goType == "*decimal.Decimal" || goType == "decimal.Decimal"
+}
+
+// columnTypePattern matches the column types imported fields keep, such as
+// int unsigned or char(8).
+var columnTypePattern = regexp.MustCompile(`^[a-z]+( ?\(\d+(,\d+)?\))?( unsigned)?( zerofill)?$`)
+
+// checkFieldOptions returns what is wrong with the column options of field,
+// keyed by option.
+func checkFieldOptions(field Field) map[string]string {
+	problems := map[string]string{}
+	fieldType := fieldTypeOf(field)
+
+	enum := fieldType.Name == "enum"
+	if enum {
+		if problem := checkEnumValues(field.Values); problem != "" {
+			problems["values"] = problem
+		}
+	} else if len(field.Values) > 0 {
+		problems["values"] = "Values only apply to enum fields"
+	}
+
+	if fieldType.Parse == "upload" {
+		for _, t := range acceptTypes(field.Accept) {
+			if !acceptPattern.MatchString(t) {
+				problems["accept"] = fmt.Sprintf("%q is not a MIME type such as image/png or image/*", t)
+			}
+		}
+		if field.MaxSize < 0 {
+			problems["maxSize"] = "Max size must not be negative"
+		}
+	} else if field.Accept != "" || field.MaxSize != 0 {
+		problems["accept"] = "Accepted types and max size only apply to upload fields"
+	}
+
+	if field.Size < 0 {
+		problems["size"] = "Size must not be negative"
+	} else if field.Size > 0 && enum {
+		problems["size"] = "Enum fields are sized by their values"
+	} else if field.Size > 0 && !isTextType(field.Type) {
+		problems["size"] = "Size only applies to text and binary fields"
+	} else if field.Size > 0 && fieldType.SQLType != "" {
+		problems["size"] = fmt.Sprintf("%s fields are always stored as %s", fieldType.Name, fieldType.SQLType)
+	}
+	if field.SQLType != "" && !columnTypePattern.MatchString(field.SQLType) {
+		problems["sqlType"] = fmt.Sprintf("%q is not a MySQL column type", field.SQLType)
+	}
+	if field.Precision < 0 || field.Scale < 0 {
+		problems["precision"] = "Precision and scale must not be negative"
+	} else if (field.Precision > 0 || field.Scale > 0) && !isDecimalType(field.Type) {
+		problems["precision"] = "Precision and scale only apply to decimal fields"
+	} else if (field.Precision > 0 || field.Scale > 0) && fieldType.SQLType != "" {
+		problems["precision"] = fmt.Sprintf("%s fields are always stored as %s", fieldType.Name, fieldType.SQLType)
+	} else if field.Scale > field.Precision {
+		problems["precision"] = "Scale must not exceed precision"
+	}
+
+	// Both end up inside a struct tag, where these would end the setting.
+	for option, value := range map[string]string{"default": field.Default, "comment": field.Comment} {
+		if strings.ContainsAny(value, ";\"`\\\n") {
+			problems[option] = fmt.Sprintf("%s must not contain ; \" ` \\ or line breaks", CapitalizeFirstLetter(option))
+		}
+	}
+	if _, ok := problems["default"]; !ok && field.Default != "" {
+		if enum {
+			if !containsString(field.Values, field.Default) {
+				problems["default"] = fmt.Sprintf("Default %q is not one of the values", field.Default)
+			}
+		} else if err := fieldType.checkValue(field.Default); err != nil {
+			name := fieldType.Name
+			if name == "" {
+				name = field.Type
+			}
+			problems["default"] = fmt.Sprintf("Default %q is not a valid %s", field.Default, name)
+		} else if _, err := sqlDefault(field); err != nil {
+			problems["default"] = fmt.Sprintf("Default %q is not a valid %s", field.Default, field.Type)
+		}
+	}
+	return problems
+}
+
+// tagCases convert a snake_case field name to the key of its json and form
+// tags under each naming convention of ProjectConfig.TagCase.
+var tagCases = map[string]func(string) string{
+	"":       func(name string) string { return name },
+	"snake":  func(name string) string { return name },
+	"camel":  func(name string) string { return lowerFirstLetter(ToCamelCase(name)) },
+	"pascal": ToCamelCase,
+	"kebab":  ToKebabCase,
+}
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPlanModuleRequirements(t *testing.T) {
+	const goMod = `module example.com/app
+
+go 1.19
+
+require (
+	github.com/gofiber/fiber/v2 v2.52.1
+	gorm.io/gorm v1.25.10
+)
+
+require github.com/jinzhu/now v1.1.5 // indirect
+`
+	tests := []struct {
+		name   string
+		goMod  string
+		fields []Field
+		want   string // go.mod as planned, empty when it is left alone
+	}{
+		{
+			name:   "decimal field",
+			goMod:  goMod,
+			fields: []Field{{Name: "price", Type: "decimal.Decimal"}},
+			want: `module example.com/app
+
+go 1.19
+
+require (
+	github.com/gofiber/fiber/v2 v2.52.1
+	github.com/shopspring/decimal v1.4.0
+	gorm.io/gorm v1.25.10
+)
+
+require github.com/jinzhu/now v1.1.5 // indirect
+`,
+		},
+		{
+			name:   "module already required",
+			goMod:  strings.Replace(goMod, "\tgorm.io/gorm", "\tgithub.com/shopspring/decimal v1.3.1\n\tgorm.io/gorm", 1),
+			fields: []Field{{Name: "price", Type: "*decimal.Decimal"}},
+		},
+		{
+			name:   "standard library types",
+			goMod:  goMod,
+			fields: []Field{{Name: "name", Type: "string"}, {Name: "born", Type: "time.Time"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chdirTemp(t)
+			if err := os.WriteFile("go.mod", []byte(test.goMod), 0644); err != nil {
+				t.Fatal(err)
+			}
+			plan := &ScaffoldPlan{}
+			if err := planModuleRequirements(plan, test.fields); err != nil {
+				t.Fatal(err)
+			}
+			if test.want == "" {
+				if len(plan.Changes) > 0 {
+					t.Errorf("planned %+v", plan.Changes)
+				}
+				return
+			}
+
+			got, _ := plan.current("go.mod")
+			if string(got) != test.want {
+				t.Errorf("go.mod:\n%s\nwant:\n%s", got, test.want)
+			}
+			sums, _ := plan.current("go.sum")
+			for _, want := range []string{"github.com/shopspring/decimal v1.4.0 h1:", "github.com/shopspring/decimal v1.4.0/go.mod h1:"} {
+				if !strings.Contains(string(sums), want) {
+					t.Errorf("go.sum lacks %q:\n%s", want, sums)
+				}
+			}
+			if content, _ := os.ReadFile("go.mod"); string(content) != test.goMod {
+				t.Errorf("go.mod was written before the plan was applied:\n%s", content)
+			}
+		})
+	}
+}
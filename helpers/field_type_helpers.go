@@ -0,0 +1,416 @@
+package helpers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FieldType is a kind of field scaffolds offer: how it is stored, declared in
+// the model, edited by forms and shown by views. Field.Kind names one.
+type FieldType struct {
+	// Name is how field specs, models.json and /dev refer to the type, such
+	// as varchar or email.
+	Name  string `json:"name"`
+	Label string `json:"label,omitempty"` // what the /dev type select shows, the name by default
+	// Aliases are other SQL type names that field specs and imported columns
+	// map to the type.
+	Aliases []string `json:"aliases,omitempty"`
+	GoType  string   `json:"goType"`
+	// SQLType is the MySQL column type, which generated models also set as
+	// the type of their gorm tag. Empty derives it from the Go type and the
+	// size, precision and scale of the field, as gorm does.
+	SQLType string `json:"sqlType,omitempty"`
+	// Gorm holds more settings for the gorm tag, such as serializer:json.
+	Gorm string `json:"gorm,omitempty"`
+	// Input is the input type forms edit the field with, or textarea for a
+	// <textarea>; text by default.
+	Input string `json:"input,omitempty"`
+	// Parse tells the edit script how to read the input into JSON: int,
+	// float, decimal, bool, datetime, json, file, enum, upload or text, the
+	// default. Upload fields hold the URL of a file kept by the storage.
+	Parse string `json:"parse,omitempty"`
+	// Format is the view function, with any leading arguments, that index
+	// and show views display the value with; empty to print it as is.
+	Format string `json:"format,omitempty"`
+	// Pattern is a regular expression every value must match as a whole. Forms
+	// check it through the pattern attribute, and defaults are checked too.
+	Pattern string `json:"pattern,omitempty"`
+	// Check reports what is wrong with value, a default, beyond the pattern.
+	Check func(value string) error `json:"-"`
+	// Fake generates an example value, shown as the placeholder of inputs.
+	Fake func(r *rand.Rand) string `json:"-"`
+	// Accept lists the MIME types upload fields accept, comma-separated as
+	// in the accept attribute of file inputs; fields may narrow it.
+	Accept string `json:"accept,omitempty"`
+	// Thumbnail keeps a thumbnail of each upload, which views show.
+	Thumbnail bool `json:"thumbnail,omitempty"`
+	// Example is the placeholder of types declared in grails.json, which
+	// cannot declare Fake.
+	Example string `json:"example,omitempty"`
+}
+
+var (
+	// fieldTypes are the builtin types and those registered by the project
+	// in Go, in the order /dev offers them.
+	fieldTypes []FieldType
+	// configFieldTypes are the types declared in grails.json, replaced each
+	// time it is loaded. They take precedence over fieldTypes.
+	configFieldTypes []FieldType
+)
+
+var parseModes = map[string]bool{
+	"int": true, "float": true, "decimal": true, "bool": true,
+	"datetime": true, "json": true, "file": true, "text": true, "enum": true,
+	"upload": true,
+}
+
+// normalizeFieldType fills in the defaults of t and reports what is wrong with
+// it.
+func normalizeFieldType(t FieldType) (FieldType, error) {
+	t.Name = strings.ToLower(strings.TrimSpace(t.Name))
+	if !identifierPattern.MatchString(t.Name) {
+		return t, fmt.Errorf("invalid field type name %q", t.Name)
+	}
+	if problem := checkGoType(t.GoType); problem != "" {
+		return t, fmt.Errorf("field type %s: Go type %s", t.Name, problem)
+	}
+	if t.Label == "" {
+		t.Label = strings.ToUpper(t.Name)
+	}
+	if t.Input == "" {
+		t.Input = "text"
+	}
+	if t.Parse == "" {
+		t.Parse = "text"
+	}
+	if !parseModes[t.Parse] {
+		return t, fmt.Errorf("field type %s: unknown parse mode %q", t.Name, t.Parse)
+	}
+	if t.Pattern != "" {
+		if strings.Contains(t.Pattern, `"`) {
+			return t, fmt.Errorf("field type %s: pattern must not contain \"", t.Name)
+		}
+		if _, err := regexp.Compile(t.Pattern); err != nil {
+			return t, fmt.Errorf("field type %s: invalid pattern: %w", t.Name, err)
+		}
+	}
+	for _, accept := range acceptTypes(t.Accept) {
+		if !acceptPattern.MatchString(accept) {
+			return t, fmt.Errorf("field type %s: %q is not a MIME type", t.Name, accept)
+		}
+	}
+	if strings.ContainsAny(t.SQLType+t.Gorm, ";\"`\\\n") {
+		return t, fmt.Errorf("field type %s: the SQL type and gorm settings must not contain ; \" ` \\ or line breaks", t.Name)
+	}
+	if t.Fake == nil && t.Example != "" {
+		example := t.Example
+		t.Fake = func(*rand.Rand) string { return example }
+	}
+	return t, nil
+}
+
+// RegisterFieldType adds t to the field types scaffolds offer, replacing the
+// one of the same name.
+func RegisterFieldType(t FieldType) error {
+	t, err := normalizeFieldType(t)
+	if err != nil {
+		return err
+	}
+	for i, existing := range fieldTypes {
+		if existing.Name == t.Name {
+			fieldTypes[i] = t
+			return nil
+		}
+	}
+	fieldTypes = append(fieldTypes, t)
+	return nil
+}
+
+// loadConfigFieldTypes replaces the field types declared in grails.json.
+func loadConfigFieldTypes(types []FieldType) error {
+	loaded := make([]FieldType, 0, len(types))
+	for _, t := range types {
+		t, err := normalizeFieldType(t)
+		if err != nil {
+			return fmt.Errorf("%w in %s", err, configFilePath)
+		}
+		loaded = append(loaded, t)
+	}
+	configFieldTypes = loaded
+	return nil
+}
+
+// FieldTypes lists every field type, in the order /dev offers them.
+func FieldTypes() []FieldType {
+	types := make([]FieldType, 0, len(fieldTypes)+len(configFieldTypes))
+	for _, t := range fieldTypes {
+		if _, overridden := findFieldType(configFieldTypes, t.Name); !overridden {
+			types = append(types, t)
+		}
+	}
+	return append(types, configFieldTypes...)
+}
+
+func findFieldType(types []FieldType, name string) (FieldType, bool) {
+	for _, t := range types {
+		if t.Name == name {
+			return t, true
+		}
+		for _, alias := range t.Aliases {
+			if alias == name {
+				return t, true
+			}
+		}
+	}
+	return FieldType{}, false
+}
+
+// LookupFieldType returns the field type called name, or having it as an
+// alias, ignoring case.
+func LookupFieldType(name string) (FieldType, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if t, ok := findFieldType(configFieldTypes, name); ok {
+		return t, true
+	}
+	return findFieldType(fieldTypes, name)
+}
+
+// fieldTypeOf returns the type of field by its Kind, else by its Go type.
+func fieldTypeOf(field Field) FieldType {
+	if field.Kind != "" {
+		if t, ok := LookupFieldType(field.Kind); ok {
+			return t
+		}
+	}
+	goType := strings.TrimPrefix(field.Type, "*")
+	for _, t := range FieldTypes() {
+		if strings.TrimPrefix(t.GoType, "*") == goType {
+			return t
+		}
+	}
+
+	fallback := FieldType{Input: "text", Parse: "text"}
+	switch {
+	case isIntegerType(goType):
+		fallback, _ = LookupFieldType("int")
+	case isDecimalType(goType):
+		fallback, _ = LookupFieldType("double")
+	}
+	fallback.Name, fallback.Label, fallback.Aliases, fallback.SQLType = "", "", nil, ""
+	fallback.GoType = field.Type
+	return fallback
+}
+
+// WithFieldKinds returns fields with the Kind of those lacking one derived
+// from their Go type, as in models.json entries written before kinds were.
+func WithFieldKinds(fields []Field) []Field {
+	withKinds := make([]Field, len(fields))
+	for i, field := range fields {
+		if field.Kind == "" {
+			field.Kind = fieldTypeOf(field).Name
+		}
+		withKinds[i] = field
+	}
+	return withKinds
+}
+
+// isEnum reports whether field is an enum, limited to its Values. Its model
+// declares a string type of its own for it, with a constant per value.
+func isEnum(field Field) bool {
+	return fieldTypeOf(field).Name == "enum"
+}
+
+// enumValuePattern matches the values of enums, which name their constants
+// and are compared as MySQL reports them, in lower case.
+var enumValuePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
+
+// checkEnumValues returns what is wrong with the values of an enum field.
+func checkEnumValues(values []string) string {
+	if len(values) == 0 {
+		return "Enum fields need at least one value"
+	}
+	seen := map[string]bool{}
+	for _, value := range values {
+		if !enumValuePattern.MatchString(value) {
+			return fmt.Sprintf("Value %q must contain only lower case letters, digits and underscores", value)
+		}
+		// Values differing only by underscores would declare the same constant.
+		if seen[ToCamelCase(value)] {
+			return fmt.Sprintf("Value %q is listed twice", value)
+		}
+		seen[ToCamelCase(value)] = true
+	}
+	return ""
+}
+
+// defaultMaxUpload is the largest upload of fields without a MaxSize.
+const defaultMaxUpload = 10 << 20
+
+var acceptPattern = regexp.MustCompile(`^[a-z]+/(\*|[a-z0-9][a-z0-9.+-]*)$`)
+
+// acceptTypes returns the MIME types of a comma-separated accept list.
+func acceptTypes(accept string) []string {
+	var types []string
+	for _, t := range strings.Split(accept, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// uploadLimits returns the storage.Limits literal generated handlers check
+// the uploads of field with.
+func uploadLimits(field Field, t FieldType) string {
+	maxSize := field.MaxSize
+	if maxSize == 0 {
+		maxSize = defaultMaxUpload
+	}
+	size := strconv.FormatInt(maxSize, 10)
+	switch {
+	case maxSize%(1<<20) == 0:
+		size = fmt.Sprintf("%d << 20", maxSize>>20)
+	case maxSize%(1<<10) == 0:
+		size = fmt.Sprintf("%d << 10", maxSize>>10)
+	}
+	limits := "storage.Limits{MaxSize: " + size
+	accept := field.Accept
+	if accept == "" {
+		accept = t.Accept
+	}
+	if types := acceptTypes(accept); len(types) > 0 {
+		limits += fmt.Sprintf(", Accept: %#v", types)
+	}
+	if t.Thumbnail {
+		limits += ", Thumbnail: true"
+	}
+	return limits + "}"
+}
+
+// ParseSize parses a number of bytes with an optional unit, as in 512KB or
+// 2MB.
+func ParseSize(value string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	shift := 0
+	for unit, s := range map[string]int{"KB": 10, "MB": 20, "GB": 30} {
+		if strings.HasSuffix(upper, unit) {
+			upper, shift = strings.TrimSpace(strings.TrimSuffix(upper, unit)), s
+			break
+		}
+	}
+	upper = strings.TrimSuffix(upper, "B")
+	size, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected bytes or a number of KB, MB or GB", value)
+	}
+	return size << shift, nil
+}
+
+// checkValue reports what is wrong with value as a value of t.
+func (t FieldType) checkValue(value string) error {
+	if t.Pattern != "" && !regexp.MustCompile(`^(?:`+t.Pattern+`)$`).MatchString(value) {
+		return fmt.Errorf("does not match %s", t.Pattern)
+	}
+	if t.Check != nil {
+		return t.Check(value)
+	}
+	return nil
+}
+
+// example returns the placeholder of the field called name, the same each
+// time it is generated so regenerated forms do not change.
+func (t FieldType) example(name string) string {
+	if t.Fake == nil {
+		return ""
+	}
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return t.Fake(rand.New(rand.NewSource(int64(hash.Sum64()))))
+}
+
+func checkInt(value string) error {
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err
+}
+
+func checkFloat(value string) error {
+	_, err := strconv.ParseFloat(value, 64)
+	return err
+}
+
+func checkBool(value string) error {
+	_, err := strconv.ParseBool(value)
+	return err
+}
+
+func checkEmail(value string) error {
+	address, err := mail.ParseAddress(value)
+	if err == nil && address.Address != value {
+		err = fmt.Errorf("%q is not a bare address", value)
+	}
+	return err
+}
+
+func checkURL(value string) error {
+	u, err := url.ParseRequestURI(value)
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = fmt.Errorf("%q is not an absolute URL", value)
+	}
+	return err
+}
+
+var exampleWords = []string{"ada", "grace", "alan", "barbara", "edsger", "margaret", "ken", "frances"}
+
+func fakeEmail(r *rand.Rand) string {
+	return exampleWords[r.Intn(len(exampleWords))] + "@example.com"
+}
+
+func fakeURL(r *rand.Rand) string {
+	return "https://example.com/" + exampleWords[r.Intn(len(exampleWords))]
+}
+
+func fakeUUID(r *rand.Rand) string {
+	return fmt.Sprintf("%08x-%04x-4%03x-%04x-%012x", r.Uint32(), r.Intn(1<<16), r.Intn(1<<12), 0x8000|r.Intn(1<<14), r.Int63n(1<<48))
+}
+
+// builtinFieldTypes are registered first, in the order /dev offers them.
+var builtinFieldTypes = []FieldType{
+	{Name: "varchar", Aliases: []string{"string", "char", "nvarchar", "nchar", "tinytext", "mediumtext", "longtext", "clob"}, GoType: "string"},
+	{Name: "text", GoType: "string", SQLType: "text", Input: "textarea"},
+	{Name: "int", Aliases: []string{"integer"}, GoType: "int", Input: "number", Parse: "int", Check: checkInt},
+	{Name: "mediumint", GoType: "int", SQLType: "mediumint", Input: "number", Parse: "int", Check: checkInt},
+	{Name: "bigint", GoType: "int64", Input: "number", Parse: "int", Check: checkInt},
+	{Name: "smallint", GoType: "int16", Input: "number", Parse: "int", Check: checkInt},
+	{Name: "tinyint", GoType: "int8", Input: "number", Parse: "int", Check: checkInt},
+	{Name: "float", GoType: "float32", Input: "number", Parse: "float", Check: checkFloat},
+	{Name: "double", Aliases: []string{"real"}, GoType: "float64", Input: "number", Parse: "float", Check: checkFloat},
+	{Name: "decimal", Aliases: []string{"numeric"}, GoType: "*decimal.Decimal", Input: "number", Parse: "decimal", Check: checkFloat},
+	{Name: "boolean", Aliases: []string{"bool"}, GoType: "bool", Input: "checkbox", Parse: "bool", Format: "formatBool", Check: checkBool},
+	{Name: "datetime", GoType: "time.Time", Input: "datetime-local", Parse: "datetime", Format: "formatTime"},
+	{Name: "date", GoType: "time.Time", SQLType: "date", Input: "date", Parse: "datetime", Format: "formatTime"},
+	{Name: "timestamp", GoType: "time.Time", SQLType: "timestamp", Input: "datetime-local", Parse: "datetime", Format: "formatTime"},
+	{Name: "time", GoType: "time.Time", SQLType: "time", Input: "datetime-local", Parse: "datetime", Format: "formatTime"},
+	{Name: "year", GoType: "time.Time", SQLType: "year", Input: "date", Parse: "datetime", Format: "formatTime"},
+	{Name: "json", GoType: "datatypes.JSON", Input: "textarea", Parse: "json", Format: `printf "%s"`},
+	{Name: "blob", Aliases: []string{"binary", "varbinary", "tinyblob", "mediumblob", "longblob"}, GoType: "[]byte", Input: "file", Parse: "file", Format: "formatBytes"},
+	{Name: "email", GoType: "string", SQLType: "varchar(255)", Input: "email", Check: checkEmail, Fake: fakeEmail},
+	{Name: "url", GoType: "string", SQLType: "varchar(2048)", Input: "url", Check: checkURL, Fake: fakeURL},
+	{Name: "enum", GoType: "string", Input: "select", Parse: "enum", Format: "formatEnum"},
+	{Name: "file", GoType: "string", SQLType: "varchar(255)", Input: "file", Parse: "upload", Format: "formatFile"},
+	{Name: "image", GoType: "string", SQLType: "varchar(255)", Input: "file", Parse: "upload", Format: "formatImage", Accept: "image/jpeg,image/png,image/gif", Thumbnail: true},
+	{Name: "uuid", GoType: "string", SQLType: "char(36)", Pattern: "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}", Fake: fakeUUID},
+}
+
+func init() {
+	for _, t := range builtinFieldTypes {
+		if err := RegisterFieldType(t); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/inflection"
+)
+
+// The rules inflection ships with, so LoadInflections can be called again
+// after grails.json changes without stacking overrides.
+var (
+	defaultIrregular   = inflection.GetIrregular()
+	defaultUncountable = inflection.GetUncountable()
+)
+
+// LoadInflections registers the irregular and uncountable words of
+// grails.json, which gorm's table names follow too.
+func LoadInflections() error {
+	config, err := ReadProjectConfig()
+	if err != nil {
+		return err
+	}
+
+	inflection.SetIrregular(defaultIrregular)
+	inflection.SetUncountable(defaultUncountable)
+	for singular, plural := range config.Inflections.Irregular {
+		if !identifierPattern.MatchString(singular) || !identifierPattern.MatchString(plural) {
+			return fmt.Errorf("invalid irregular inflection %q: %q in %s", singular, plural, configFilePath)
+		}
+		inflection.AddIrregular(singular, plural)
+	}
+	for _, word := range config.Inflections.Uncountable {
+		if !identifierPattern.MatchString(word) {
+			return fmt.Errorf("invalid uncountable inflection %q in %s", word, configFilePath)
+		}
+	}
+	if len(config.Inflections.Uncountable) > 0 {
+		inflection.AddUncountable(config.Inflections.Uncountable...)
+	}
+	return nil
+}
+
+// Pluralize returns the plural of the last word of name, keeping its case
+// style: "blog_post" becomes "blog_posts" and "Person" becomes "People".
+func Pluralize(name string) string {
+	return inflection.Plural(name)
+}
+
+// Singularize returns the singular of the last word of name.
+func Singularize(name string) string {
+	return inflection.Singular(name)
+}
+
+// ToKebabCase converts a snake_case or CamelCase string to kebab-case.
+func ToKebabCase(str string) string {
+	return strings.ReplaceAll(ToSnakeCase(str), "_", "-")
+}
+
+// Humanize turns a snake_case or CamelCase name into a label: "created_at"
+// and "CreatedAt" both become "Created at".
+func Humanize(str string) string {
+	words := strings.Fields(strings.ReplaceAll(ToSnakeCase(str), "_", " "))
+	if len(words) > 0 && words[len(words)-1] == "id" && len(words) > 1 {
+		words = words[:len(words)-1]
+	}
+	return CapitalizeFirstLetter(strings.Join(words, " "))
+}
@@ -1,12 +1,51 @@
 package helpers
 
 import (
+	"bytes"
 	"encoding/json"
 	"io/ioutil"
 	"os"
 )
 
-type ModelsJSON map[string][]Field
+type ModelsJSON map[string]ModelSchema
+
+// ModelSchema is the models.json entry of a scaffolded model. Models without
+// associations are stored as a plain list of fields, as they always were.
+type ModelSchema struct {
+	Fields       []Field       `json:"fields"`
+	Associations []Association `json:"associations,omitempty"`
+}
+
+func (m ModelSchema) MarshalJSON() ([]byte, error) {
+	if len(m.Associations) == 0 {
+		return json.Marshal(m.Fields)
+	}
+	type schema ModelSchema
+	return json.Marshal(schema(m))
+}
+
+func (m *ModelSchema) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		*m = ModelSchema{}
+		return json.Unmarshal(data, &m.Fields)
+	}
+	type schema ModelSchema
+	return json.Unmarshal(data, (*schema)(m))
+}
+
+// lookup returns the entry of the model names describes and its key: the
+// model name, or a legacy key deriving the same names, such as users.
+func (m ModelsJSON) lookup(names ScaffoldNames) (string, ModelSchema, bool) {
+	if schema, ok := m[names.Model]; ok {
+		return names.Model, schema, true
+	}
+	for key, schema := range m {
+		if NewScaffoldNames(normalizeName(key)).Model == names.Model {
+			return key, schema, true
+		}
+	}
+	return "", ModelSchema{}, false
+}
 
 const jsonFilePath = "models.json"
 
@@ -18,17 +57,28 @@ func ReadModelsFromJSON() (ModelsJSON, error) {
 		}
 		return nil, err
 	}
+	return parseModelsJSON(file)
+}
 
-	var models ModelsJSON
-	err = json.Unmarshal(file, &models)
+// parseModelsJSON decodes models.json content; nil content is an empty map.
+func parseModelsJSON(file []byte) (ModelsJSON, error) {
+	models := ModelsJSON{}
+	if file == nil {
+		return models, nil
+	}
+	err := json.Unmarshal(file, &models)
 	if err != nil {
 		return nil, err
 	}
 	return models, nil
 }
 
+func marshalModelsJSON(models ModelsJSON) ([]byte, error) {
+	return json.MarshalIndent(models, "", "  ")
+}
+
 func WriteModelsToJSON(models ModelsJSON) error {
-	file, err := json.MarshalIndent(models, "", "  ")
+	file, err := marshalModelsJSON(models)
 	if err != nil {
 		return err
 	}
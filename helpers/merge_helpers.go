@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"bytes"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Conflict markers written around the two versions of a conflicting region.
+const (
+	conflictStart  = "<<<<<<< current\n"
+	conflictMiddle = "=======\n"
+	conflictEnd    = ">>>>>>> generated\n"
+)
+
+// mergeHunk replaces the base lines [start, end) of one side with lines.
+type mergeHunk struct {
+	start, end int
+	lines      []string
+	generated  bool // whether the hunk comes from the generated side
+}
+
+// diffHunks groups the edit script turning base into other into hunks.
+func diffHunks(base, other []string, generated bool) []mergeHunk {
+	var hunks []mergeHunk
+	var hunk *mergeHunk
+	i := 0
+	for _, op := range diffLines(base, other) {
+		if op.kind == ' ' {
+			if hunk != nil {
+				hunks = append(hunks, *hunk)
+				hunk = nil
+			}
+			i++
+			continue
+		}
+		if hunk == nil {
+			hunk = &mergeHunk{start: i, end: i, generated: generated}
+		}
+		if op.kind == '-' {
+			i++
+			hunk.end = i
+		} else {
+			hunk.lines = append(hunk.lines, op.line)
+		}
+	}
+	if hunk != nil {
+		hunks = append(hunks, *hunk)
+	}
+	return hunks
+}
+
+// applyHunks returns base[start:end] with hunks, which lie inside it, applied.
+func applyHunks(base []string, start, end int, hunks []mergeHunk) []string {
+	var out []string
+	pos := start
+	for _, hunk := range hunks {
+		out = append(out, base[pos:hunk.start]...)
+		out = append(out, hunk.lines...)
+		pos = hunk.end
+	}
+	return append(out, base[pos:end]...)
+}
+
+// merge3 merges the hand edits from base to current with the regenerated
+// changes from base to generated. ok is false if conflict markers were added.
+func merge3(base, current, generated []byte) (merged []byte, ok bool) {
+	if string(current) == string(generated) {
+		return generated, true
+	}
+	if base == nil {
+		return []byte(conflictStart + string(current) + conflictMiddle + string(generated) + conflictEnd), false
+	}
+
+	baseLines := splitLines(string(base))
+	hunks := append(diffHunks(baseLines, splitLines(string(current)), false), diffHunks(baseLines, splitLines(string(generated)), true)...)
+	sort.SliceStable(hunks, func(i, j int) bool {
+		return hunks[i].start < hunks[j].start
+	})
+
+	var out strings.Builder
+	ok = true
+	pos := 0
+	for i := 0; i < len(hunks); {
+		// Collect the hunks overlapping the first one, transitively.
+		start, end := hunks[i].start, hunks[i].end
+		j := i + 1
+		for j < len(hunks) && (hunks[j].start < end || hunks[j].start == start) {
+			if hunks[j].end > end {
+				end = hunks[j].end
+			}
+			j++
+		}
+		group := hunks[i:j]
+		i = j
+
+		var ours, theirs []mergeHunk
+		for _, hunk := range group {
+			if hunk.generated {
+				theirs = append(theirs, hunk)
+			} else {
+				ours = append(ours, hunk)
+			}
+		}
+
+		out.WriteString(strings.Join(baseLines[pos:start], ""))
+		pos = end
+		oursText := strings.Join(applyHunks(baseLines, start, end, ours), "")
+		theirsText := strings.Join(applyHunks(baseLines, start, end, theirs), "")
+		switch {
+		case len(theirs) == 0 || oursText == theirsText:
+			out.WriteString(oursText)
+		case len(ours) == 0:
+			out.WriteString(theirsText)
+		case start == end:
+			// Both sides only insert lines here.
+			out.WriteString(oursText + theirsText)
+		default:
+			ok = false
+			out.WriteString(conflictStart + oursText + conflictMiddle + theirsText + conflictEnd)
+		}
+	}
+	out.WriteString(strings.Join(baseLines[pos:], ""))
+	return []byte(out.String()), ok
+}
+
+// unalignGo strips gofmt's column alignment so a new struct field does not
+// change its neighbours' lines.
+func unalignGo(src []byte) []byte {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return src
+	}
+	var out bytes.Buffer
+	config := printer.Config{Mode: printer.RawFormat, Tabwidth: 8}
+	if err := config.Fprint(&out, fset, file); err != nil {
+		return src
+	}
+	return out.Bytes()
+}
+
+// mergeFile is merge3 for the file path. Go files are merged unaligned and
+// gofmt'd again once merged cleanly.
+func mergeFile(path string, base, current, generated []byte) ([]byte, bool) {
+	if filepath.Ext(path) != ".go" {
+		return merge3(base, current, generated)
+	}
+	if base != nil {
+		base = unalignGo(base)
+	}
+	merged, ok := merge3(base, unalignGo(current), unalignGo(generated))
+	if !ok {
+		return merged, false
+	}
+	if formatted, err := format.Source(merged); err == nil {
+		return formatted, true
+	}
+	return merged, true
+}
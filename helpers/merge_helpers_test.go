@@ -0,0 +1,88 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	const base = "a\nb\nc\nd\n"
+	tests := []struct {
+		name               string
+		base, current, gen string
+		nilBase            bool
+		want               string
+		ok                 bool
+	}{
+		{name: "unchanged", base: base, current: base, gen: base, want: base, ok: true},
+		{name: "hand edit only", base: base, current: "a\nB\nc\nd\n", gen: base, want: "a\nB\nc\nd\n", ok: true},
+		{name: "generated change only", base: base, current: base, gen: "a\nb\nc\nD\n", want: "a\nb\nc\nD\n", ok: true},
+		{name: "separate regions", base: base, current: "A\nb\nc\nd\n", gen: "a\nb\nc\nD\n", want: "A\nb\nc\nD\n", ok: true},
+		{name: "same change on both sides", base: base, current: "a\nX\nc\nd\n", gen: "a\nX\nc\nd\n", want: "a\nX\nc\nd\n", ok: true},
+		{name: "both insert at the same place", base: base, current: "a\nb\nmine\nc\nd\n", gen: "a\nb\ntheirs\nc\nd\n", want: "a\nb\nmine\ntheirs\nc\nd\n", ok: true},
+		{name: "hand deletion", base: base, current: "a\nd\n", gen: "a\nb\nc\nd\ne\n", want: "a\nd\ne\n", ok: true},
+		{
+			name: "overlapping changes", base: base, current: "a\nmine\nc\nd\n", gen: "a\ntheirs\nc\nd\n",
+			want: "a\n" + conflictStart + "mine\n" + conflictMiddle + "theirs\n" + conflictEnd + "c\nd\n",
+		},
+		{
+			name: "never recorded", nilBase: true, current: "x\n", gen: "y\n",
+			want: conflictStart + "x\n" + conflictMiddle + "y\n" + conflictEnd,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := []byte(test.base)
+			if test.nilBase {
+				base = nil
+			}
+			merged, ok := merge3(base, []byte(test.current), []byte(test.gen))
+			if string(merged) != test.want || ok != test.ok {
+				t.Errorf("merge3 = %q, %v, want %q, %v", merged, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestApplyRefusesConflicts(t *testing.T) {
+	chdirTemp(t)
+	plan := &ScaffoldPlan{
+		Model:     "Post",
+		Changes:   []FileChange{{Path: "post.html", After: []byte(conflictStart + "a\n" + conflictMiddle + "b\n" + conflictEnd)}},
+		Conflicts: []string{"post.html"},
+	}
+	var conflict *MergeConflictError
+	if err := plan.Apply(); !errors.As(err, &conflict) {
+		t.Fatalf("Apply = %v, want a *MergeConflictError", err)
+	}
+	if _, err := os.Stat("post.html"); !os.IsNotExist(err) {
+		t.Fatal("Apply wrote a file with conflict markers")
+	}
+
+	plan.KeepConflicts = true
+	if err := plan.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("post.html"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestModelsJSONLookup(t *testing.T) {
+	models := ModelsJSON{"users": {}, "BlogPost": {}}
+	tests := []struct {
+		table, key string
+		ok         bool
+	}{
+		{"users", "users", true},
+		{"blog_post", "BlogPost", true},
+		{"comment", "", false},
+	}
+	for _, test := range tests {
+		key, _, ok := models.lookup(NewScaffoldNames(test.table))
+		if key != test.key || ok != test.ok {
+			t.Errorf("lookup(%s) = %q, %v, want %q, %v", test.table, key, ok, test.key, test.ok)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigrationsTable records the versions of the migrations applied to the
+// database.
+const schemaMigrationsTable = "schema_migrations"
+
+// SchemaMigration is a row of schemaMigrationsTable.
+type SchemaMigration struct {
+	Version   string `gorm:"primaryKey;size:14"`
+	Name      string `gorm:"size:255;not null"`
+	AppliedAt time.Time
+	// Dirty is up or down while the script of that direction runs, and stays
+	// so when it fails.
+	Dirty string `gorm:"size:4;not null;default:''"`
+}
+
+func (SchemaMigration) TableName() string {
+	return schemaMigrationsTable
+}
+
+// appliedMigrations creates schemaMigrationsTable if needed and returns the
+// rows recorded in it, keyed by version.
+func appliedMigrations(db *gorm.DB) (map[string]SchemaMigration, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", schemaMigrationsTable, err)
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := map[string]SchemaMigration{}
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// sqlStatements splits a migration script into its statements, which end with
+// a semicolon at the end of a line. Lines commented out with -- are skipped.
+func sqlStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";\n") {
+		if statement = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(statement), ";")); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// runMigrationScript executes the statements of the script at path.
+func runMigrationScript(db *gorm.DB, path string) error {
+	script, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, statement := range sqlStatements(string(script)) {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ErrUnknownMigration is returned for a version no migration has.
+var ErrUnknownMigration = errors.New("unknown migration")
+
+// ErrNoSQLMigrations is returned when rolling back a project without SQL
+// migrations.
+var ErrNoSQLMigrations = errors.New("there are no SQL migrations in " + migrationsDir)
+
+// ErrMissingMigration is returned when reverting an applied migration whose
+// scripts are gone from migrationsDir.
+var ErrMissingMigration = errors.New("missing migration")
+
+// ErrDirtyMigration is returned while a migration that failed halfway is not
+// resolved.
+var ErrDirtyMigration = errors.New("dirty migration")
+
+// MigrationStatus is a migration and when it was applied, if it was.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	// Missing is set for versions the database recorded whose scripts are
+	// gone from migrationsDir.
+	Missing bool `json:"missing,omitempty"`
+	// Dirty is the direction of the step that failed halfway, if one did.
+	Dirty string `json:"dirty,omitempty"`
+}
+
+// MigrationStatuses lists every migration, applied or pending, oldest first.
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := ReadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			status.Dirty = row.Dirty
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		row := row
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: row.Version, Name: row.Name},
+			AppliedAt: &row.AppliedAt,
+			Missing:   true,
+			Dirty:     row.Dirty,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// applyMigration runs the up script of migration and records it.
+func applyMigration(db *gorm.DB, migration Migration) error {
+	row := SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now(), Dirty: "up"}
+	if err := db.Create(&row).Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := runMigrationScript(tx, migration.Up); err != nil {
+			return err
+		}
+		return tx.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Update("dirty", "").Error
+	})
+}
+
+// recordMigration records migration as applied.
+func recordMigration(db *gorm.DB, migration Migration) error {
+	row := SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+	return db.Create(&row).Error
+}
+
+// revertMigration runs the down script of migration and forgets it.
+func revertMigration(db *gorm.DB, status MigrationStatus) error {
+	migration := status.Migration
+	if status.Missing {
+		return fmt.Errorf("%w: %s_%s is applied but its scripts are gone from %s", ErrMissingMigration, migration.Version, migration.Name, migrationsDir)
+	}
+	if migration.Down == "" {
+		return fmt.Errorf("migration %s_%s has no down script", migration.Version, migration.Name)
+	}
+	if err := db.Model(&SchemaMigration{}).Where("version = ?", migration.Version).Update("dirty", "down").Error; err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := runMigrationScript(tx, migration.Down); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+	})
+}
+
+// checkClean returns an ErrDirtyMigration error for the first migration of
+// statuses that failed halfway.
+func checkClean(statuses []MigrationStatus) error {
+	for _, status := range statuses {
+		if status.Dirty != "" {
+			return fmt.Errorf("%w: %s_%s failed halfway %s; undo its changes by hand, then run migrate resolve %s",
+				ErrDirtyMigration, status.Version, status.Name, status.Dirty, status.Version)
+		}
+	}
+	return nil
+}
+
+// MigrateResolve clears the dirty migration version once its partial changes
+// were undone by hand.
+func MigrateResolve(db *gorm.DB, version string) error {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return err
+	}
+	row, ok := applied[version]
+	switch {
+	case !ok:
+		return fmt.Errorf("%w %s", ErrUnknownMigration, version)
+	case row.Dirty == "up":
+		return db.Delete(&SchemaMigration{}, "version = ?", version).Error
+	case row.Dirty == "down":
+		return db.Model(&SchemaMigration{}).Where("version = ?", version).Update("dirty", "").Error
+	}
+	return fmt.Errorf("migration %s_%s did not fail", row.Version, row.Name)
+}
+
+// MigrateUp applies the pending migrations, oldest first. A migration that
+// fails halfway is left dirty until resolved, as MySQL commits DDL as it goes.
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	applied, _, err := MigrateTo(db, "")
+	return applied, err
+}
+
+// MigrateDown reverts the steps most recently applied migrations, newest
+// first, and returns the ones reverted.
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("cannot roll back %d migrations", steps)
+	}
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, ErrNoSQLMigrations
+	}
+	if err := checkClean(statuses); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < steps; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		if err := revertMigration(db, statuses[i]); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, statuses[i].Migration)
+	}
+	return reverted, nil
+}
+
+// MigrateRedo reverts the most recently applied migration and applies it
+// again, returning it.
+func MigrateRedo(db *gorm.DB) (*Migration, error) {
+	reverted, err := MigrateDown(db, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(reverted) == 0 {
+		return nil, fmt.Errorf("no migration has been applied")
+	}
+	return &reverted[0], applyMigration(db, reverted[0])
+}
+
+// MigrateTo applies or reverts migrations until version is the last applied
+// one; "0" reverts them all.
+func MigrateTo(db *gorm.DB, version string) (applied, reverted []Migration, err error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkClean(statuses); err != nil {
+		return nil, nil, err
+	}
+	if version != "" && version != "0" {
+		known := false
+		for _, status := range statuses {
+			known = known || status.Version == version
+		}
+		if !known {
+			return nil, nil, fmt.Errorf("%w %s", ErrUnknownMigration, version)
+		}
+	}
+	after := func(status MigrationStatus) bool {
+		return version != "" && status.Version > version
+	}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if statuses[i].AppliedAt == nil || !after(statuses[i]) {
+			continue
+		}
+		if err := revertMigration(db, statuses[i]); err != nil {
+			return nil, reverted, err
+		}
+		reverted = append(reverted, statuses[i].Migration)
+	}
+	for _, status := range statuses {
+		if status.AppliedAt != nil || after(status) {
+			continue
+		}
+		if err := applyMigration(db, status.Migration); err != nil {
+			return applied, reverted, err
+		}
+		applied = append(applied, status.Migration)
+	}
+	return applied, reverted, nil
+}
+
+const migrateUsage = "usage: migrate [up | status | down [n] | redo | to <version> | resolve <version>]"
+
+// RunMigrateCommand runs the migrate subcommand in args, up by default, and
+// prints what it did.
+func RunMigrateCommand(db *gorm.DB, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	var applied, reverted []Migration
+	var err error
+	switch {
+	case command == "up" && len(args) == 0:
+		applied, err = MigrateUp(db)
+	case command == "status" && len(args) == 0:
+		return printMigrationStatus(db)
+	case command == "down" && len(args) <= 1:
+		steps := 1
+		if len(args) == 1 {
+			if steps, err = strconv.Atoi(args[0]); err != nil || steps < 1 {
+				return fmt.Errorf("down takes a positive number of migrations, not %q", args[0])
+			}
+		}
+		reverted, err = MigrateDown(db, steps)
+	case command == "redo" && len(args) == 0:
+		var migration *Migration
+		migration, err = MigrateRedo(db)
+		if migration != nil {
+			reverted = []Migration{*migration}
+			if err == nil {
+				applied = reverted
+			}
+		}
+	case command == "to" && len(args) == 1:
+		applied, reverted, err = MigrateTo(db, args[0])
+	case command == "resolve" && len(args) == 1:
+		if err := MigrateResolve(db, args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("%s%sRESOLVED%s\t%s\n", Bold, Green, Reset, args[0])
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	for _, migration := range reverted {
+		fmt.Printf("%s%sREVERTED%s\t%s_%s\n", Bold, Yellow, Reset, migration.Version, migration.Name)
+	}
+	for _, migration := range applied {
+		fmt.Printf("%s%sMIGRATED%s\t%s_%s\n", Bold, Green, Reset, migration.Version, migration.Name)
+	}
+	if err == nil && len(applied) == 0 && len(reverted) == 0 {
+		fmt.Println("Nothing to migrate")
+	}
+	return err
+}
+
+// printMigrationStatus lists every migration with when it was applied.
+func printMigrationStatus(db *gorm.DB) error {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		fmt.Println("There are no SQL migrations in " + migrationsDir)
+		return nil
+	}
+	for _, status := range statuses {
+		state := fmt.Sprintf("%s%-8s%s %-19s", Yellow, "pending", Reset, "")
+		if status.AppliedAt != nil {
+			state = fmt.Sprintf("%s%-8s%s %s", Green, "applied", Reset, status.AppliedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		line := fmt.Sprintf("%s  %s_%s", state, status.Version, status.Name)
+		if status.Missing {
+			line += fmt.Sprintf(" %s(file missing)%s", Red, Reset)
+		}
+		if status.Dirty != "" {
+			line += fmt.Sprintf(" %s(failed halfway %s)%s", Red, status.Dirty, Reset)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
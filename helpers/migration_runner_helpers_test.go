@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSQLStatements(t *testing.T) {
+	script := "CREATE TABLE `a` (\n  `id` bigint\n);\n\nALTER TABLE `a` ADD COLUMN `b` longtext COMMENT 'x;y';\n"
+	want := []string{"CREATE TABLE `a` (\n  `id` bigint\n)", "ALTER TABLE `a` ADD COLUMN `b` longtext COMMENT 'x;y'"}
+	if got := sqlStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("sqlStatements = %q, want %q", got, want)
+	}
+	if got := sqlStatements("-- only a comment;\n"); len(got) != 0 {
+		t.Errorf("sqlStatements of a comment = %q", got)
+	}
+	if got := sqlStatements("-- a comment\n" + script); !reflect.DeepEqual(got, want) {
+		t.Errorf("sqlStatements after a comment = %q, want %q", got, want)
+	}
+}
+
+func TestCheckClean(t *testing.T) {
+	clean := []MigrationStatus{{Migration: Migration{Version: "20240101000000", Name: "a"}}}
+	if err := checkClean(clean); err != nil {
+		t.Errorf("checkClean = %v", err)
+	}
+	dirty := append(clean, MigrationStatus{Migration: Migration{Version: "20240102000000", Name: "b"}, Dirty: "up"})
+	if err := checkClean(dirty); !errors.Is(err, ErrDirtyMigration) {
+		t.Errorf("checkClean = %v, want ErrDirtyMigration", err)
+	}
+}
+
+func TestRevertMissingMigration(t *testing.T) {
+	status := MigrationStatus{Migration: Migration{Version: "20240101000000", Name: "a"}, Missing: true}
+	if err := revertMigration(nil, status); !errors.Is(err, ErrMissingMigration) {
+		t.Errorf("revertMigration = %v, want ErrMissingMigration", err)
+	}
+}
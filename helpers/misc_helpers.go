@@ -8,44 +8,57 @@ import (
 type Field struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
+	// Column options, rendered into the gorm tag and the generated forms.
+	Nullable  bool   `json:"nullable,omitempty"`
+	Unique    bool   `json:"unique,omitempty"`
+	Index     bool   `json:"index,omitempty"`
+	Default   string `json:"default,omitempty"`
+	Size      int    `json:"size,omitempty"`
+	Precision int    `json:"precision,omitempty"`
+	Scale     int    `json:"scale,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+	// Kind names the FieldType the field was declared with, which may say
+	// more than its Go type, such as email.
+	Kind string `json:"kind,omitempty"`
+	// Values are the values an enum field is limited to, in order.
+	Values []string `json:"values,omitempty"`
+	// Accept narrows the MIME types an upload field accepts, comma-separated,
+	// and MaxSize is its largest upload in bytes, 10 MB when 0.
+	Accept  string `json:"accept,omitempty"`
+	MaxSize int64  `json:"maxSize,omitempty"`
+	// SQLType keeps the column type of an imported field, such as int
+	// unsigned, when its field type would not produce it.
+	SQLType string `json:"sqlType,omitempty"`
 }
 
-func ToGoType(sqlType string) string {
-	// Regular expression to match SQL types with optional length or precision
-	re := regexp.MustCompile(`([a-zA-Z]+)(\(\d+\))?`)
+// sqlBaseTypeRe matches the name of an SQL type, without its length or
+// precision.
+var sqlBaseTypeRe = regexp.MustCompile(`^\s*([a-zA-Z]+)`)
 
-	// Extract base type and optional length/precision
-	matches := re.FindStringSubmatch(strings.ToUpper(sqlType))
-	if len(matches) < 2 {
-		return "string"
+// ToGoType returns the Go type of the field type named by sqlType, such as
+// varchar(100) or email. Unknown types are strings.
+func ToGoType(sqlType string) string {
+	if t, ok := lookupSQLType(sqlType); ok {
+		return t.GoType
 	}
-	baseType := matches[1]
+	return "string"
+}
 
-	switch baseType {
-	case "VARCHAR", "CHAR", "NVARCHAR", "NCHAR", "CLOB", "TEXT":
-		return "string"
-	case "INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT":
-		return "int"
-	case "FLOAT", "DOUBLE", "REAL", "DECIMAL", "NUMERIC":
-		return "float64"
-	case "DATE", "DATETIME", "TIMESTAMP", "TIME", "YEAR":
-		return "time.Time"
-	case "BINARY", "VARBINARY", "BLOB", "LONGBLOB", "MEDIUMBLOB", "TINYBLOB":
-		return "[]byte"
-	case "BOOL", "BOOLEAN":
-		return "bool"
-	default:
-		return "string"
+// lookupSQLType returns the field type named by sqlType, ignoring its length
+// or precision. TINYINT(1) is MySQL's boolean.
+func lookupSQLType(sqlType string) (FieldType, bool) {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(sqlType)), "tinyint(1)") {
+		return LookupFieldType("boolean")
 	}
+	match := sqlBaseTypeRe.FindStringSubmatch(sqlType)
+	if match == nil {
+		return FieldType{}, false
+	}
+	return LookupFieldType(match[1])
 }
 
+// GetHTMLInputType returns the input type forms edit a field of goType with,
+// or "textarea" for a <textarea>.
 func GetHTMLInputType(goType string) string {
-	switch goType {
-	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
-		return "number"
-	case "bool":
-		return "checkbox"
-	default:
-		return "text"
-	}
+	return fieldTypeOf(Field{Type: goType}).Input
 }
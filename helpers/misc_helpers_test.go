@@ -0,0 +1,50 @@
+package helpers
+
+import "testing"
+
+func TestToGoType(t *testing.T) {
+	tests := map[string]string{
+		"VARCHAR(100)":  "string",
+		"TEXT":          "string",
+		"INT":           "int",
+		"MEDIUMINT":     "int",
+		"mediumint(9)":  "int",
+		"TINYINT":       "int8",
+		"TINYINT(4)":    "int8",
+		"TINYINT(1)":    "bool",
+		"tinyint(1)":    "bool",
+		"BOOLEAN":       "bool",
+		"DECIMAL(10,2)": "*decimal.Decimal",
+		"DATETIME":      "time.Time",
+		"DATE":          "time.Time",
+		"TIMESTAMP":     "time.Time",
+		"TIME":          "time.Time",
+		"YEAR":          "time.Time",
+		"JSON":          "datatypes.JSON",
+		"LONGBLOB":      "[]byte",
+	}
+	for sqlType, want := range tests {
+		if got := ToGoType(sqlType); got != want {
+			t.Errorf("ToGoType(%q) = %q, want %q", sqlType, got, want)
+		}
+	}
+}
+
+func TestSQLDataTypeOfRegisteredTypes(t *testing.T) {
+	tests := map[string]string{
+		"mediumint": "mediumint",
+		"time":      "time",
+		"year":      "year",
+		"boolean":   "boolean",
+	}
+	for kind, want := range tests {
+		fieldType, ok := LookupFieldType(kind)
+		if !ok {
+			t.Errorf("%s is not registered", kind)
+			continue
+		}
+		if got := sqlDataType(Field{Name: "x", Type: fieldType.GoType, Kind: kind}); got != want {
+			t.Errorf("sqlDataType(%s) = %q, want %q", kind, got, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"path/filepath"
+	"unicode"
+)
+
+// ScaffoldNames holds every spelling of a scaffolded model's name, shown for
+// "blog_post".
+type ScaffoldNames struct {
+	Table       string // blog_post, as given to the generator
+	Model       string // BlogPost, the struct and handler name suffix
+	ModelPlural string // BlogPosts
+	Var         string // blogPost, local variables and the view data key
+	VarPlural   string // blogPosts
+	Human       string // Blog post
+	HumanPlural string // Blog posts
+	ViewDir     string // blog_posts, under views/
+	RoutePath   string // /blog-posts
+}
+
+// NewScaffoldNames derives the names of a scaffold from its snake_case table
+// name.
+func NewScaffoldNames(tableName string) ScaffoldNames {
+	model := ToCamelCase(tableName)
+	return ScaffoldNames{
+		Table:       tableName,
+		Model:       model,
+		ModelPlural: Pluralize(model),
+		Var:         lowerFirstLetter(model),
+		VarPlural:   lowerFirstLetter(Pluralize(model)),
+		Human:       Humanize(tableName),
+		HumanPlural: Humanize(Pluralize(tableName)),
+		ViewDir:     Pluralize(tableName),
+		RoutePath:   "/" + ToKebabCase(Pluralize(tableName)),
+	}
+}
+
+// ModelFile is the path of the generated model.
+func (n ScaffoldNames) ModelFile() string {
+	return filepath.Join("models", n.Table+".go")
+}
+
+// HandlerFile is the path of the generated handlers.
+func (n ScaffoldNames) HandlerFile() string {
+	return filepath.Join("handlers", n.Table+"_handlers.go")
+}
+
+// View is the name handlers render the view action by, such as
+// "blog_posts/index".
+func (n ScaffoldNames) View(action string) string {
+	return n.ViewDir + "/" + action
+}
+
+// ViewFile is the path of the view of action.
+func (n ScaffoldNames) ViewFile(action string) string {
+	return filepath.Join("views", n.ViewDir, action+".html")
+}
+
+// Files lists the files CreateModel writes: the model, the handlers and the
+// views, in that order.
+func (n ScaffoldNames) Files() []string {
+	files := []string{n.ModelFile(), n.HandlerFile()}
+	for _, action := range scaffoldViews {
+		files = append(files, n.ViewFile(action))
+	}
+	return files
+}
+
+// scaffoldViews are the views generated for every scaffold.
+var scaffoldViews = []string{"index", "insert", "show", "edit", "delete"}
+
+func lowerFirstLetter(str string) string {
+	runes := []rune(str)
+	if len(runes) == 0 {
+		return str
+	}
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// handlerIdentifiers are the names generated handlers already use, which a
+// model's variables must not shadow.
+var handlerIdentifiers = map[string]bool{
+	"c": true, "db": true, "err": true, "result": true,
+	"fiber": true, "gorm": true, "models": true, "handlers": true,
+}
+
+// clashesWithHandlers reports whether the variables of n would shadow an
+// identifier of the generated handlers.
+func (n ScaffoldNames) clashesWithHandlers() bool {
+	return handlerIdentifiers[n.Var] || handlerIdentifiers[n.VarPlural]
+}
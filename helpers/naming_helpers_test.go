@@ -0,0 +1,68 @@
+package helpers
+
+import "testing"
+
+func TestPluralize(t *testing.T) {
+	tests := map[string]string{
+		"post":       "posts",
+		"category":   "categories",
+		"box":        "boxes",
+		"person":     "people",
+		"Person":     "People",
+		"blog_post":  "blog_posts",
+		"BlogPost":   "BlogPosts",
+		"news":       "news",
+		"order_item": "order_items",
+	}
+	for name, want := range tests {
+		if got := Pluralize(name); got != want {
+			t.Errorf("Pluralize(%q) = %q, want %q", name, got, want)
+		}
+		if got := Singularize(want); got != name {
+			t.Errorf("Singularize(%q) = %q, want %q", want, got, name)
+		}
+	}
+}
+
+func TestNewScaffoldNames(t *testing.T) {
+	tests := []ScaffoldNames{
+		{
+			Table:       "blog_post",
+			Model:       "BlogPost",
+			ModelPlural: "BlogPosts",
+			Var:         "blogPost",
+			VarPlural:   "blogPosts",
+			Human:       "Blog post",
+			HumanPlural: "Blog posts",
+			ViewDir:     "blog_posts",
+			RoutePath:   "/blog-posts",
+		},
+		{
+			Table:       "category",
+			Model:       "Category",
+			ModelPlural: "Categories",
+			Var:         "category",
+			VarPlural:   "categories",
+			Human:       "Category",
+			HumanPlural: "Categories",
+			ViewDir:     "categories",
+			RoutePath:   "/categories",
+		},
+		{
+			Table:       "person",
+			Model:       "Person",
+			ModelPlural: "People",
+			Var:         "person",
+			VarPlural:   "people",
+			Human:       "Person",
+			HumanPlural: "People",
+			ViewDir:     "people",
+			RoutePath:   "/people",
+		},
+	}
+	for _, want := range tests {
+		if got := NewScaffoldNames(want.Table); got != want {
+			t.Errorf("NewScaffoldNames(%q) = %+v, want %+v", want.Table, got, want)
+		}
+	}
+}
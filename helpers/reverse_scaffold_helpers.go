@@ -0,0 +1,414 @@
+package helpers
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// sqlTypeLength matches the length of varchar(100) or the precision and scale
+// of decimal(10,2).
+var sqlTypeLength = regexp.MustCompile(`\((\d+)(?:,\s*(\d+))?\)`)
+
+// SQLField returns the field named name for a column of sqlType, keeping its
+// size, precision and enum values.
+func SQLField(name, sqlType string) Field {
+	field := Field{Name: name, Type: "string", Kind: "varchar"}
+	if t, ok := lookupSQLType(sqlType); ok {
+		field.Type, field.Kind = t.GoType, t.Name
+	}
+	if isEnum(field) {
+		field.Values = enumValues(sqlType)
+		return field
+	}
+	if m := sqlTypeLength.FindStringSubmatch(sqlType); m != nil && fieldTypeOf(field).SQLType == "" {
+		length, _ := strconv.Atoi(m[1])
+		scale, _ := strconv.Atoi(m[2])
+		switch {
+		case isTextType(field.Type):
+			field.Size = length
+		case isDecimalType(strings.TrimPrefix(field.Type, "*")):
+			field.Precision, field.Scale = length, scale
+		}
+	}
+	return field
+}
+
+// columnField returns the field for a column of the database, whose type is
+// kept as is so importing it never changes the column.
+func columnField(name, columnType string) Field {
+	field := SQLField(name, columnType)
+	if isIntegerType(field.Type) && strings.HasSuffix(columnType, " unsigned") {
+		field.Type = "u" + field.Type
+		field.Kind = fieldTypeOf(Field{Type: field.Type}).Name
+	}
+	if sqlDataType(field) != columnType {
+		field.SQLType = columnType
+	}
+	return field
+}
+
+// enumValues returns the values listed by an enum column type, quoted or not.
+func enumValues(sqlType string) []string {
+	start, end := strings.Index(sqlType, "("), strings.LastIndex(sqlType, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+	var values []string
+	for _, value := range strings.Split(sqlType[start+1:end], ",") {
+		if value = strings.Trim(strings.TrimSpace(value), `'"`); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// ImportableTable is a database table and whether it can be scaffolded.
+type ImportableTable struct {
+	Table string `json:"table"`
+	Model string `json:"model"`
+	// Reason tells why the table cannot be imported, empty if it can.
+	Reason string `json:"reason,omitempty"`
+	// Join is set for join tables, which become many-to-many associations
+	// of the models they join instead of models of their own.
+	Join bool `json:"join,omitempty"`
+}
+
+// ImportedModel is the scaffold a table is imported as.
+type ImportedModel struct {
+	Table        string        `json:"table"`
+	Model        string        `json:"model"`
+	Fields       []Field       `json:"fields"`
+	Associations []Association `json:"associations"`
+}
+
+// joinedTables returns the two tables table joins, if it is a join table: two
+// columns making up its primary key, each a foreign key to an id.
+func joinedTables(table *sqlTable) (left, right string, ok bool) {
+	if len(table.Columns) != 2 || len(table.PrimaryKey) != 2 || len(table.ForeignKeys) != 2 {
+		return "", "", false
+	}
+	refs := map[string]string{}
+	for _, key := range table.ForeignKeys {
+		if key.RefColumn != "id" {
+			return "", "", false
+		}
+		refs[key.Column] = key.RefTable
+	}
+	left, right = refs[table.Columns[0].Name], refs[table.Columns[1].Name]
+	return left, right, left != "" && right != ""
+}
+
+// importableTables lists the tables of database no model maps to yet.
+func importableTables(database sqlSchema) ([]ImportableTable, error) {
+	models, err := ReadModelsFromJSON()
+	if err != nil {
+		return nil, err
+	}
+	structs, _, err := structSchema(&ScaffoldPlan{})
+	if err != nil {
+		return nil, err
+	}
+	modeled := schemaOf(models)
+
+	var tables []ImportableTable
+	for _, name := range sortedTableNames(database) {
+		if name == schemaMigrationsTable || structs[name] != nil || (modeled[name] != nil && !modeled[name].External) {
+			continue
+		}
+		table := database[name]
+		names := NewScaffoldNames(Singularize(name))
+		importable := ImportableTable{Table: name, Model: names.Model}
+		if left, right, ok := joinedTables(table); ok {
+			importable.Join = true
+			importable.Reason = fmt.Sprintf("it joins %s and %s", left, right)
+		} else if table.column("id") == nil || len(table.PrimaryKey) != 1 || table.PrimaryKey[0] != "id" {
+			importable.Reason = "it has no id primary key, which scaffolds take from gorm.Model"
+		} else if names.ViewDir != name {
+			importable.Reason = fmt.Sprintf("gorm would store %s in %s; add an inflection to %s", names.Model, names.ViewDir, configFilePath)
+		} else if problem := checkName(names.Table); problem != "" {
+			importable.Reason = "its name " + problem
+		}
+		tables = append(tables, importable)
+	}
+	return tables, nil
+}
+
+// ImportableTables lists the tables of the database db is connected to that
+// no model maps to yet, and whether each can be scaffolded.
+func ImportableTables(db *gorm.DB) ([]ImportableTable, error) {
+	if err := LoadProjectConfig(); err != nil {
+		return nil, err
+	}
+	database, err := databaseSchema(db)
+	if err != nil {
+		return nil, err
+	}
+	return importableTables(database)
+}
+
+// unquoteDefault turns a default read from information_schema back into the
+// value a field option holds.
+func unquoteDefault(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
+
+// importedModel describes table as a scaffold, its foreign keys to models as
+// belongs-to associations.
+func importedModel(table *sqlTable, models map[string]string) ImportedModel {
+	names := NewScaffoldNames(Singularize(table.Name))
+	imported := ImportedModel{Table: table.Name, Model: names.Model, Fields: []Field{}, Associations: []Association{}}
+
+	references := map[string]string{}
+	for _, key := range table.ForeignKeys {
+		if key.RefColumn == "id" && models[key.RefTable] != "" && key.Column == Singularize(key.RefTable)+"_id" {
+			references[key.Column] = key.RefTable
+		}
+	}
+	indexed := map[string]bool{}
+	for _, index := range table.Indexes {
+		if len(index.Columns) == 1 {
+			indexed[index.Columns[0]] = true
+		}
+	}
+
+	for _, column := range table.Columns {
+		if gormModelColumns[column.Name] {
+			continue
+		}
+		if ref, ok := references[column.Name]; ok {
+			imported.Associations = append(imported.Associations, Association{Kind: BelongsTo, Model: Singularize(ref)})
+			continue
+		}
+		field := columnField(column.Name, column.Type)
+		field.Nullable = column.Null
+		field.Unique = column.Unique
+		field.Index = indexed[column.Name] && !column.Unique
+		field.Default = unquoteDefault(column.Default)
+		field.Comment = column.Comment
+		imported.Fields = append(imported.Fields, field)
+	}
+	return imported
+}
+
+// importOrder describes tables as scaffolds, ordered so the models each one
+// associates with come first.
+func importOrder(database sqlSchema, tables []string) ([]ImportedModel, error) {
+	importable, err := importableTables(database)
+	if err != nil {
+		return nil, err
+	}
+	byTable := map[string]ImportableTable{}
+	for _, table := range importable {
+		byTable[table.Table] = table
+	}
+
+	existing, err := existingModels()
+	if err != nil {
+		return nil, err
+	}
+	// models maps the tables that will have a model to it.
+	models := map[string]string{}
+	for model := range existing {
+		models[tableName(model)] = model
+	}
+	selected := map[string]bool{}
+	for _, table := range tables {
+		info, ok := byTable[table]
+		switch {
+		case !ok && database[table] == nil:
+			return nil, stepError(ErrCodeInvalidRequest, StepCheck, "", fmt.Errorf("there is no %s table", table))
+		case !ok:
+			return nil, stepError(ErrCodeInvalidRequest, StepCheck, "", fmt.Errorf("a model already maps to %s", table))
+		case info.Reason != "":
+			return nil, stepError(ErrCodeInvalidRequest, StepCheck, "", fmt.Errorf("%s cannot be imported: %s", table, info.Reason))
+		}
+		selected[table] = true
+		models[table] = info.Model
+	}
+
+	pending := map[string]ImportedModel{}
+	depends := map[string][]string{}
+	for table := range selected {
+		imported := importedModel(database[table], models)
+		for _, association := range imported.Associations {
+			depends[table] = append(depends[table], NewScaffoldNames(association.Model).ViewDir)
+		}
+		pending[table] = imported
+	}
+	for _, join := range importable {
+		if !join.Join {
+			continue
+		}
+		left, right, _ := joinedTables(database[join.Table])
+		for _, pair := range [][2]string{{left, right}, {right, left}} {
+			owner, other := pair[0], pair[1]
+			imported, ok := pending[owner]
+			if !ok || models[other] == "" || joinNames(NewScaffoldNames(Singularize(owner)), NewScaffoldNames(Singularize(other))).ViewDir != join.Table {
+				continue
+			}
+			imported.Associations = append(imported.Associations, Association{Kind: ManyToMany, Model: Singularize(other)})
+			pending[owner] = imported
+			depends[owner] = append(depends[owner], other)
+		}
+	}
+
+	var ordered []ImportedModel
+	for len(pending) > 0 {
+		var ready []string
+		for table := range pending {
+			blocked := false
+			for _, dependency := range depends[table] {
+				if _, ok := pending[dependency]; ok && dependency != table {
+					blocked = true
+				}
+			}
+			if !blocked {
+				ready = append(ready, table)
+			}
+		}
+		if len(ready) == 0 {
+			var cycle []string
+			for table := range pending {
+				cycle = append(cycle, table)
+			}
+			sort.Strings(cycle)
+			err := fmt.Errorf("the foreign keys of %s form a cycle; import them one at a time", strings.Join(cycle, ", "))
+			return nil, stepError(ErrCodeInvalidRequest, StepCheck, "", err)
+		}
+		sort.Strings(ready)
+		for _, table := range ready {
+			ordered = append(ordered, pending[table])
+			delete(pending, table)
+		}
+	}
+	return ordered, nil
+}
+
+// PlanImport describes the scaffolds ImportTables creates for tables of the
+// database db is connected to, in the order it creates them.
+func PlanImport(db *gorm.DB, tables []string) ([]ImportedModel, error) {
+	if err := LoadProjectConfig(); err != nil {
+		return nil, err
+	}
+	database, err := databaseSchema(db)
+	if err != nil {
+		return nil, err
+	}
+	return importOrder(database, tables)
+}
+
+// ImportTables scaffolds each of tables of the database through the same
+// pipeline as CreateModel, and returns the scaffolds created before any error.
+func ImportTables(db *gorm.DB, tables []string) ([]ImportedModel, error) {
+	ordered, err := PlanImport(db, tables)
+	if err != nil {
+		return nil, err
+	}
+	database, err := databaseSchema(db)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := appliedMigrations(db); err != nil {
+		return nil, err
+	}
+
+	var created []ImportedModel
+	for _, imported := range ordered {
+		plan, err := PlanModel(Singularize(imported.Table), imported.Fields, imported.Associations)
+		if err != nil {
+			return created, err
+		}
+		existing, err := planImportMigrations(plan, database, imported.Table)
+		if err != nil {
+			return created, stepError(ErrCodeGenerate, StepMigration, migrationsDir, fmt.Errorf("failed to write SQL migration: %w", err))
+		}
+		if err := plan.Apply(); err != nil {
+			return created, err
+		}
+		if err := snapshotScaffold(plan.Model, plan.Generated); err != nil {
+			return created, stepError(ErrCodeWrite, StepSnapshot, snapshotDir, fmt.Errorf("failed to record generated files: %w", err))
+		}
+		created = append(created, imported)
+		if existing != nil {
+			if err := recordMigration(db, *existing); err != nil {
+				return created, err
+			}
+		}
+	}
+	return created, nil
+}
+
+// planImportMigrations splits the migration of plan into one creating the
+// tables database already has, which it returns, and one for the rest.
+func planImportMigrations(plan *ScaffoldPlan, database sqlSchema, table string) (*Migration, error) {
+	change := plan.find(jsonFilePath)
+	if change == nil {
+		return nil, nil
+	}
+	changes := plan.Changes[:0]
+	for _, c := range plan.Changes {
+		match := migrationFileRe.FindStringSubmatch(filepath.Base(c.Path))
+		if filepath.Dir(c.Path) != migrationsDir || c.Before != nil || match != nil && match[2] == baselineMigrationName {
+			changes = append(changes, c)
+		}
+	}
+	plan.Changes = changes
+
+	before, err := parseModelsJSON(change.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := parseModelsJSON(change.After)
+	if err != nil {
+		return nil, err
+	}
+	beforeSchema, touched := schemaOf(before), schemaOf(after)
+	for name, t := range touched {
+		if reflect.DeepEqual(t, beforeSchema[name]) {
+			delete(touched, name)
+		}
+	}
+	current := reconcileSchemas(database, touched)
+	// Columns the database already has are never changed by an import.
+	for name, t := range touched {
+		if actual := current[name]; actual != nil {
+			for i, column := range t.Columns {
+				if existing := actual.column(column.Name); existing != nil {
+					t.Columns[i] = *existing
+				}
+			}
+		}
+	}
+
+	existing := sqlSchema{}
+	for name, t := range current {
+		if !t.External {
+			existing[name] = t
+		}
+	}
+	migrations, err := plannedMigrations(plan)
+	if err != nil {
+		return nil, err
+	}
+	var recorded *Migration
+	if len(existing) > 0 {
+		if err := planMigrationFiles(plan, migrations, "import_"+table, sqlSchema{}, existing); err != nil {
+			return nil, err
+		}
+		if migrations, err = plannedMigrations(plan); err != nil {
+			return nil, err
+		}
+		recorded = &migrations[len(migrations)-1]
+	}
+	return recorded, planMigrationFiles(plan, migrations, "complete_import_"+table, current, touched)
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPlanImportMigrations(t *testing.T) {
+	posts := &sqlTable{
+		Name:       "posts",
+		Columns:    []sqlColumn{{Name: "id", Type: "bigint unsigned", AutoIncrement: true}, {Name: "title", Type: "longtext"}},
+		PrimaryKey: []string{"id"},
+	}
+	items := &sqlTable{
+		Name: "items",
+		Columns: []sqlColumn{
+			{Name: "id", Type: "int", AutoIncrement: true},
+			{Name: "qty", Type: "mediumint"},
+			{Name: "opens", Type: "time", Null: true},
+			{Name: "n", Type: "int unsigned"},
+			{Name: "total", Type: "bigint unsigned"},
+			{Name: "small", Type: "smallint unsigned"},
+			{Name: "active", Type: "boolean"},
+			{Name: "code", Type: "char(8)"},
+			{Name: "notes", Type: "mediumtext", Null: true},
+			{Name: "sold_at", Type: "datetime"},
+			{Name: "made", Type: "year"},
+			{Name: "price", Type: "decimal(8,3)"},
+		},
+		PrimaryKey: []string{"id"},
+	}
+	tests := []struct {
+		name     string
+		table    *sqlTable // the table the model is imported from
+		database sqlSchema
+		recorded bool
+		scripts  map[string][]string // statements of the up scripts, by migration name
+	}{
+		{
+			name:     "existing table",
+			table:    posts,
+			database: sqlSchema{"posts": posts, "other": {Name: "other"}},
+			recorded: true,
+			scripts: map[string][]string{
+				"import_posts":          {"CREATE TABLE IF NOT EXISTS `posts` (\n  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n  `title` longtext NOT NULL,\n  PRIMARY KEY (`id`)\n);"},
+				"complete_import_posts": {"ADD COLUMN `created_at`", "ADD COLUMN `deleted_at`", "CREATE INDEX `idx_posts_deleted_at`"},
+			},
+		},
+		{
+			name:     "missing table",
+			table:    posts,
+			database: sqlSchema{},
+			scripts: map[string][]string{
+				"complete_import_posts": {"CREATE TABLE IF NOT EXISTS `posts`"},
+			},
+		},
+		{
+			name:     "column types",
+			table:    items,
+			database: sqlSchema{"items": items},
+			recorded: true,
+			scripts: map[string][]string{
+				"import_items": {
+					"`id` int NOT NULL AUTO_INCREMENT",
+					"`qty` mediumint NOT NULL",
+					"`opens` time NULL",
+					"`n` int unsigned NOT NULL",
+					"`total` bigint unsigned NOT NULL",
+					"`small` smallint unsigned NOT NULL",
+					"`active` boolean NOT NULL",
+					"`code` char(8) NOT NULL",
+					"`notes` mediumtext NULL",
+					"`sold_at` datetime NOT NULL",
+					"`made` year NOT NULL",
+					"`price` decimal(8,3) NOT NULL",
+				},
+				"complete_import_items": {"ADD COLUMN `created_at`"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chdirTemp(t)
+			imported := importedModel(test.table, nil)
+			// PlanModel stores the fields as validation normalizes them.
+			_, fields, _, err := ValidateScaffold(Singularize(test.table.Name), imported.Fields, imported.Associations)
+			if err != nil {
+				t.Fatalf("imported fields are invalid: %v", err)
+			}
+			models := ModelsJSON{imported.Model: {Fields: fields}}
+			modeled := schemaOf(models)[test.table.Name]
+			for _, column := range test.table.Columns[1:] {
+				if got := modeled.column(column.Name); got == nil || got.Type != column.Type {
+					t.Errorf("column %s %s is modeled as %+v", column.Name, column.Type, got)
+				}
+			}
+			after, err := json.Marshal(models)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan := &ScaffoldPlan{Changes: []FileChange{
+				{Path: jsonFilePath, Before: []byte("{}"), After: after},
+				{Path: migrationsDir + "/20240101000000_create_" + test.table.Name + ".up.sql", After: []byte("planned from models.json\n")},
+			}}
+			recorded, err := planImportMigrations(plan, test.database, test.table.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (recorded != nil) != test.recorded || recorded != nil && recorded.Name != "import_"+test.table.Name {
+				t.Errorf("recorded %+v", recorded)
+			}
+
+			written := map[string]string{}
+			for _, change := range plan.Changes[1:] {
+				if match := migrationFileRe.FindStringSubmatch(strings.TrimPrefix(change.Path, migrationsDir+"/")); match != nil && match[3] == "up" {
+					written[match[2]] = string(change.After)
+				}
+			}
+			if len(written) != len(test.scripts) {
+				t.Fatalf("wrote migrations %v", written)
+			}
+			for name, statements := range test.scripts {
+				for _, statement := range statements {
+					if !strings.Contains(written[name], statement) {
+						t.Errorf("%s lacks %q:\n%s", name, statement, written[name])
+					}
+				}
+			}
+			if complete := written["complete_import_"+test.table.Name]; test.recorded && (strings.Contains(complete, "MODIFY") || strings.Contains(complete, "`title`")) {
+				t.Errorf("complete_import_%s changes existing columns:\n%s", test.table.Name, complete)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package helpers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+const routesFilePath = "internals/routes.go"
+
+const defaultRoutesFile = `package internals
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func SetupRoutes(app *fiber.App, dbGorm *gorm.DB) {
+}
+`
+
+// routeRegistrationCode renders the route group of a scaffolded model using
+// the parameter names SetupRoutes actually declares.
+func routeRegistrationCode(data scaffoldTemplateData, appVar, dbVar string) (string, error) {
+	data.AppVar, data.DBVar = appVar, dbVar
+	code, err := renderScaffoldTemplate("routes.go.tmpl", data)
+	return "\n" + string(code), err
+}
+
+// routeGroupPath returns the path of stmt when it is a `x := app.Group("/path")`
+// assignment.
+func routeGroupPath(stmt ast.Stmt, appVar string) (string, bool) {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || len(assign.Rhs) != 1 {
+		return "", false
+	}
+	call, ok := assign.Rhs[0].(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Group" {
+		return "", false
+	}
+	if recv, ok := sel.X.(*ast.Ident); !ok || recv.Name != appVar {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	path, err := strconv.Unquote(lit.Value)
+	return path, err == nil
+}
+
+// insertRoutes adds the route group of the scaffold described by data at the
+// end of SetupRoutes in src and returns the formatted result.
+func insertRoutes(src []byte, data scaffoldTemplateData) ([]byte, error) {
+	names := data.ScaffoldNames
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, routesFilePath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := findFuncDecl(file, "SetupRoutes")
+	if fn == nil || fn.Body == nil {
+		return nil, fmt.Errorf("%s has no SetupRoutes function", routesFilePath)
+	}
+	appVar := paramNameOfType(fn, "fiber", "App")
+	dbVar := paramNameOfType(fn, "gorm", "DB")
+	if appVar == "" || dbVar == "" {
+		return nil, fmt.Errorf("SetupRoutes in %s must take *fiber.App and *gorm.DB parameters", routesFilePath)
+	}
+
+	for _, stmt := range fn.Body.List {
+		path, ok := routeGroupPath(stmt, appVar)
+		if !ok {
+			continue
+		}
+		if isScaffoldGroup(path, names) {
+			return nil, fmt.Errorf("routes for %s are already registered", path)
+		}
+		if groupVar, ok := stmt.(*ast.AssignStmt).Lhs[0].(*ast.Ident); ok && groupVar.Name == names.Model {
+			return nil, fmt.Errorf("a route group named %s already exists", names.Model)
+		}
+	}
+
+	code, err := routeRegistrationCode(data, appVar, dbVar)
+	if err != nil {
+		return nil, err
+	}
+	if src, err = appendStatements(src, "SetupRoutes", code); err != nil {
+		return nil, fmt.Errorf("failed to add the generated routes: %v", err)
+	}
+
+	modulePath, err := ModulePath()
+	if err != nil {
+		return nil, err
+	}
+	return ensureImports(src, "github.com/gofiber/fiber/v2", "gorm.io/gorm", modulePath+"/handlers")
+}
+
+// isScaffoldGroup reports whether a route group path belongs to names.
+func isScaffoldGroup(path string, names ScaffoldNames) bool {
+	return path == names.RoutePath || path == "/"+names.ModelPlural
+}
+
+func planRoutesCode(plan *ScaffoldPlan, data scaffoldTemplateData) error {
+	content, err := plan.current(routesFilePath)
+	if err != nil {
+		return err
+	}
+	if content == nil {
+		content = []byte(defaultRoutesFile)
+	}
+
+	newContent, err := insertRoutes(content, data)
+	if err != nil {
+		return err
+	}
+	return plan.write(routesFilePath, newContent)
+}
+
+// removeRoutes strips the route group of names from SetupRoutes. It returns
+// nil if the group is not registered.
+func removeRoutes(src []byte, names ScaffoldNames) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, routesFilePath, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := findFuncDecl(file, "SetupRoutes")
+	if fn == nil || fn.Body == nil {
+		return nil, fmt.Errorf("%s has no SetupRoutes function", routesFilePath)
+	}
+	appVar := paramNameOfType(fn, "fiber", "App")
+
+	groupVar := ""
+	var spans []lineSpan
+	for _, stmt := range fn.Body.List {
+		if path, ok := routeGroupPath(stmt, appVar); ok && isScaffoldGroup(path, names) {
+			groupVar = stmt.(*ast.AssignStmt).Lhs[0].(*ast.Ident).Name
+			from := fset.Position(stmt.Pos()).Line
+			if comment := leadingComment(fset, file, stmt); comment != nil {
+				from = fset.Position(comment.Pos()).Line
+			}
+			// Take the blank line separating the group from the previous one too.
+			if isBlankLine(src, from-1) {
+				from--
+			}
+			spans = append(spans, lineSpan{from, fset.Position(stmt.End()).Line})
+			continue
+		}
+		if groupVar != "" && callsMethodOn(stmt, groupVar) {
+			spans = append(spans, lineSpan{fset.Position(stmt.Pos()).Line, fset.Position(stmt.End()).Line})
+		}
+	}
+	if groupVar == "" {
+		return nil, nil
+	}
+
+	out, err := format.Source(removeLines(src, spans))
+	if err != nil {
+		return nil, err
+	}
+	modulePath, err := ModulePath()
+	if err != nil {
+		return nil, err
+	}
+	return removeImportIfUnused(out, modulePath+"/handlers", "handlers")
+}
+
+// callsMethodOn reports whether stmt is a bare call of a method on recv.
+func callsMethodOn(stmt ast.Stmt, recv string) bool {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return false
+	}
+	call, ok := expr.X.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	return ok && ident.Name == recv
+}
+
+func planRoutesRemoval(plan *ScaffoldPlan, names ScaffoldNames) error {
+	content, err := plan.current(routesFilePath)
+	if err != nil || content == nil {
+		return err
+	}
+
+	newContent, err := removeRoutes(content, names)
+	if err != nil || newContent == nil {
+		return err
+	}
+	return plan.write(routesFilePath, newContent)
+}
@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"go/parser"
+	"go/token"
+	"go/types"
+	pathpkg "path"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInsertRoutes(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "helper and closure after SetupRoutes",
+			src: `package internals
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func SetupRoutes(r *fiber.App, gdb *gorm.DB) {
+	r.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("home")
+	})
+}
+
+var notFound = func(c *fiber.Ctx) error {
+	return c.SendStatus(404)
+}
+
+func helper() {
+	if true {
+	}
+}
+`,
+			want: `package internals
+
+import (
+	"example.com/app/handlers"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func SetupRoutes(r *fiber.App, gdb *gorm.DB) {
+	r.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("home")
+	})
+
+	// BlogPost routes
+	BlogPost := r.Group("/blog-posts")
+	BlogPost.Get("/", handlers.GetBlogPosts(gdb))
+	BlogPost.Get("/insert", handlers.InsertBlogPost())
+	BlogPost.Post("/", handlers.CreateBlogPost(gdb))
+	BlogPost.Get("/:id", handlers.ShowBlogPost(gdb))
+	BlogPost.Get("/:id/edit", handlers.EditBlogPost(gdb))
+	BlogPost.Put("/:id", handlers.UpdateBlogPost(gdb))
+	BlogPost.Get("/:id/delete", handlers.DeleteBlogPost(gdb))
+	BlogPost.Delete("/:id", handlers.DestroyBlogPost(gdb))
+}
+
+var notFound = func(c *fiber.Ctx) error {
+	return c.SendStatus(404)
+}
+
+func helper() {
+	if true {
+	}
+}
+`,
+		},
+		{
+			name: "empty SetupRoutes with separate imports",
+			src: `package internals
+
+import "github.com/gofiber/fiber/v2"
+import "gorm.io/gorm"
+
+func SetupRoutes(app *fiber.App, db *gorm.DB) {}
+`,
+			want: `package internals
+
+import (
+	"example.com/app/handlers"
+	"github.com/gofiber/fiber/v2"
+)
+import "gorm.io/gorm"
+
+func SetupRoutes(app *fiber.App, db *gorm.DB) {
+	// BlogPost routes
+	BlogPost := app.Group("/blog-posts")
+	BlogPost.Get("/", handlers.GetBlogPosts(db))
+	BlogPost.Get("/insert", handlers.InsertBlogPost())
+	BlogPost.Post("/", handlers.CreateBlogPost(db))
+	BlogPost.Get("/:id", handlers.ShowBlogPost(db))
+	BlogPost.Get("/:id/edit", handlers.EditBlogPost(db))
+	BlogPost.Put("/:id", handlers.UpdateBlogPost(db))
+	BlogPost.Get("/:id/delete", handlers.DeleteBlogPost(db))
+	BlogPost.Delete("/:id", handlers.DestroyBlogPost(db))
+}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chdirTemp(t)
+			writeGoMod(t)
+			data, err := newScaffoldTemplateData(NewScaffoldNames("blog_post"), nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := insertRoutes([]byte(test.src), data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+			checkImported(t, got)
+
+			removed, err := removeRoutes(got, data.ScaffoldNames)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := insertRoutes(removed, data); err != nil {
+				t.Errorf("routes removed by removeRoutes cannot be inserted again: %v", err)
+			}
+		})
+	}
+}
+
+// checkImported fails t when src uses a package it does not import.
+func checkImported(t *testing.T, src []byte) {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported := map[string]bool{}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := pathpkg.Base(path)
+		if strings.HasPrefix(name, "v") && strings.Trim(name[1:], "0123456789") == "" {
+			name = pathpkg.Base(pathpkg.Dir(path))
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imported[name] = true
+	}
+	for _, ident := range file.Unresolved {
+		if !imported[ident.Name] && types.Universe.Lookup(ident.Name) == nil {
+			t.Errorf("%s is used but not imported:\n%s", ident.Name, src)
+		}
+	}
+}
+
+func TestInsertRoutesRefusesDuplicates(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	data, err := newScaffoldTemplateData(NewScaffoldNames("blog_post"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := []byte(`package internals
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func SetupRoutes(app *fiber.App, db *gorm.DB) {
+	BlogPosts := app.Group("/BlogPosts")
+	BlogPosts.Get("/", func(c *fiber.Ctx) error { return nil })
+	_ = db
+}
+`)
+	if _, err := insertRoutes(src, data); err == nil {
+		t.Error("registered the routes of a scaffold twice")
+	}
+}
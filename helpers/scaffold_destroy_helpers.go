@@ -0,0 +1,174 @@
+package helpers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+)
+
+// DestroyModel removes everything CreateModel generated for tableName. Unless
+// force is set, it refuses if a generated file was edited by hand.
+func DestroyModel(tableName string, force bool) error {
+	plan, err := PlanDestroy(tableName, force)
+	if err != nil {
+		return err
+	}
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	if err := removeSnapshot(plan.Model); err != nil {
+		return stepError(ErrCodeWrite, StepSnapshot, snapshotDir, err)
+	}
+	return nil
+}
+
+// PlanDestroy renders the changes DestroyModel makes for tableName in memory.
+func PlanDestroy(tableName string, force bool) (*ScaffoldPlan, error) {
+	if err := LoadProjectConfig(); err != nil {
+		return nil, stepError(ErrCodeInvalidRequest, StepCheck, configFilePath, err)
+	}
+	names := NewScaffoldNames(tableName)
+	modelName := names.Model
+	plan := &ScaffoldPlan{Model: modelName}
+	files := names.Files()
+
+	models, err := ReadModelsFromJSON()
+	if err != nil {
+		return nil, stepError(ErrCodeGenerate, StepModelsJSON, jsonFilePath, err)
+	}
+	schema, inJSON := models[modelName]
+	if !inJSON && !anyFileExists(files) {
+		return nil, stepError(ErrCodeNotFound, StepCheck, "", fmt.Errorf("there is no %s scaffold to destroy", modelName))
+	}
+	associations := schema.Associations
+	files = append(files, joinFiles(names, associations)...)
+
+	// Back references would otherwise count as models referencing this one.
+	if err := planAssociationsRemoval(plan, names, associations); err != nil {
+		return nil, err
+	}
+	dependents, err := referencingModels(plan, modelName, names.ModelFile())
+	if err != nil {
+		return nil, stepError(ErrCodeGenerate, StepCheck, "models", err)
+	}
+	if len(dependents) > 0 {
+		err := fmt.Errorf("%s is referenced by %s, destroy those first", modelName, strings.Join(dependents, ", "))
+		return nil, stepError(ErrCodeConflict, StepCheck, names.ModelFile(), err)
+	}
+
+	if !force {
+		edited, err := editedFiles(modelName, files)
+		if err != nil {
+			return nil, stepError(ErrCodeGenerate, StepCheck, snapshotDir, err)
+		}
+		if len(edited) > 0 {
+			return nil, stepError(ErrCodeEditedFiles, StepCheck, "", &EditedFilesError{Model: modelName, Files: edited})
+		}
+	}
+
+	for _, file := range files {
+		if err := plan.remove(file); err != nil {
+			return nil, stepError(ErrCodeGenerate, StepCheck, file, err)
+		}
+	}
+	if err := planRoutesRemoval(plan, names); err != nil {
+		return nil, stepError(ErrCodeGenerate, StepRoutes, routesFilePath, fmt.Errorf("failed to remove routes: %w", err))
+	}
+	if err := planModelJSONRemoval(plan, modelName); err != nil {
+		return nil, stepError(ErrCodeGenerate, StepModelsJSON, jsonFilePath, err)
+	}
+	if err := planSchemaMigration(plan, "drop_"+names.ViewDir); err != nil {
+		return nil, stepError(ErrCodeGenerate, StepMigration, migrationsDir, fmt.Errorf("failed to write SQL migration: %w", err))
+	}
+	return plan, nil
+}
+
+func anyFileExists(paths []string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func planModelJSONRemoval(plan *ScaffoldPlan, modelName string) error {
+	content, err := plan.current(jsonFilePath)
+	if err != nil {
+		return err
+	}
+	models, err := parseModelsJSON(content)
+	if err != nil {
+		return err
+	}
+	if _, ok := models[modelName]; !ok {
+		return nil
+	}
+
+	delete(models, modelName)
+	newContent, err := marshalModelsJSON(models)
+	if err != nil {
+		return err
+	}
+	return plan.write(jsonFilePath, newContent)
+}
+
+// referencingModels lists the models other than skip with a field holding
+// modelName.
+func referencingModels(plan *ScaffoldPlan, modelName, skip string) ([]string, error) {
+	paths, err := modelFiles(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	var dependents []string
+	for _, path := range paths {
+		if path == skip {
+			continue
+		}
+		content, err := plan.current(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, content, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				st, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					if fieldTypeName(field.Type) == modelName {
+						dependents = append(dependents, typeSpec.Name.Name)
+						break
+					}
+				}
+			}
+		}
+	}
+	return dependents, nil
+}
+
+// fieldTypeName unwraps pointer and slice types down to a plain type name.
+func fieldTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return fieldTypeName(t.X)
+	case *ast.ArrayType:
+		return fieldTypeName(t.Elt)
+	}
+	return ""
+}
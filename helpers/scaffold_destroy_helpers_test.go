@@ -0,0 +1,22 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestPlanDestroyNotScaffolded(t *testing.T) {
+	chdirTemp(t)
+	if err := os.WriteFile(jsonFilePath, []byte(`{"users": [{"name": "Name", "type": "string"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tableName := range []string{"users", "post"} {
+		_, err := PlanDestroy(tableName, false)
+		var scaffoldErr *ScaffoldError
+		if !errors.As(err, &scaffoldErr) || scaffoldErr.Code != ErrCodeNotFound {
+			t.Errorf("PlanDestroy(%q) = %v, want a %s error", tableName, err, ErrCodeNotFound)
+		}
+	}
+}
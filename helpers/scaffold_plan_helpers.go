@@ -0,0 +1,244 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// stagingRoot holds the temporary directories a plan is staged in. It lives
+// inside the project so staged files can be renamed into place atomically.
+const stagingRoot = ".grails"
+
+// FileChange is one file a scaffold creates, modifies or removes. Before is
+// nil for new files and After is nil for removed ones.
+type FileChange struct {
+	Path   string
+	Before []byte
+	After  []byte
+}
+
+// ScaffoldPlan holds every change a scaffold command would make, rendered in
+// memory so it can be previewed before touching the tree.
+type ScaffoldPlan struct {
+	Model   string
+	Changes []FileChange
+	// Generated holds the files rendered from templates as rendered, which are
+	// recorded in .grails/generated once the plan is applied.
+	Generated map[string][]byte
+	// Conflicts lists the files whose merge with hand edits left conflict
+	// markers, which keep the plan from being applied.
+	Conflicts []string
+	// KeepConflicts lets Apply write the files listed in Conflicts, markers
+	// and all, for them to be resolved by hand.
+	KeepConflicts bool
+}
+
+func (p *ScaffoldPlan) find(path string) *FileChange {
+	for i := range p.Changes {
+		if p.Changes[i].Path == path {
+			return &p.Changes[i]
+		}
+	}
+	return nil
+}
+
+// current returns the content path would have once the changes planned so
+// far are applied, or nil when it would not exist.
+func (p *ScaffoldPlan) current(path string) ([]byte, error) {
+	if change := p.find(path); change != nil {
+		return change.After, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// set records that path ends up holding content, nil meaning removed. Later
+// changes to a path replace earlier ones so each file is committed once.
+func (p *ScaffoldPlan) set(path string, content []byte) error {
+	if change := p.find(path); change != nil {
+		change.After = content
+		return nil
+	}
+
+	before, err := p.current(path)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(before, content) && (before == nil) == (content == nil) {
+		return nil
+	}
+	p.Changes = append(p.Changes, FileChange{Path: path, Before: before, After: content})
+	return nil
+}
+
+// write plans path to hold content.
+func (p *ScaffoldPlan) write(path string, content []byte) error {
+	if content == nil {
+		content = []byte{}
+	}
+	return p.set(path, content)
+}
+
+// generate plans path to hold content and records content as its generated
+// version.
+func (p *ScaffoldPlan) generate(path string, content []byte) error {
+	if err := p.write(path, content); err != nil {
+		return err
+	}
+	if p.Generated == nil {
+		p.Generated = map[string][]byte{}
+	}
+	p.Generated[path] = content
+	return nil
+}
+
+// remove plans path to be deleted, if it exists.
+func (p *ScaffoldPlan) remove(path string) error {
+	return p.set(path, nil)
+}
+
+// Diff renders the plan as a unified diff against the current tree.
+func (p *ScaffoldPlan) Diff() string {
+	var out strings.Builder
+	for _, change := range p.Changes {
+		out.WriteString(unifiedDiff(filepath.ToSlash(change.Path), change.Before, change.After))
+	}
+	return out.String()
+}
+
+// viewFuncs are the functions generated views may call besides the builtins,
+// so they can be parsed the way the html engine will.
+var viewFuncs = template.FuncMap{
+	"embed": func() template.HTML { return "" },
+}
+
+func init() {
+	for name, fn := range ViewFuncs {
+		viewFuncs[name] = fn
+	}
+}
+
+// validateFile checks that content parses as the kind of file path names.
+func validateFile(path string, content []byte) error {
+	switch filepath.Ext(path) {
+	case ".go":
+		_, err := parser.ParseFile(token.NewFileSet(), path, content, parser.AllErrors)
+		return err
+	case ".html":
+		_, err := template.New(path).Funcs(viewFuncs).Parse(string(content))
+		return err
+	case ".json":
+		if !json.Valid(content) {
+			return fmt.Errorf("invalid JSON")
+		}
+	}
+	return nil
+}
+
+// Validate parses every file the plan writes and reports the first one that
+// is invalid. Conflicts kept for hand resolution are not parsed.
+func (p *ScaffoldPlan) Validate() error {
+	for _, change := range p.Changes {
+		if change.After == nil || p.KeepConflicts && containsString(p.Conflicts, change.Path) {
+			continue
+		}
+		if err := validateFile(change.Path, change.After); err != nil {
+			return &ScaffoldError{Code: ErrCodeInvalidOutput, Step: StepValidate, Path: change.Path, Err: err}
+		}
+	}
+	return nil
+}
+
+// Apply validates the plan, stages its files and moves them into place,
+// restoring the tree if any step fails. Conflicts are refused unless kept.
+func (p *ScaffoldPlan) Apply() error {
+	if len(p.Conflicts) > 0 && !p.KeepConflicts {
+		return stepError(ErrCodeMergeConflict, StepMerge, "", &MergeConflictError{Model: p.Model, Files: p.Conflicts})
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(stagingRoot, os.ModePerm); err != nil {
+		return &ScaffoldError{Code: ErrCodeWrite, Step: StepWrite, Path: stagingRoot, Err: err}
+	}
+	staging, err := os.MkdirTemp(stagingRoot, "staging-")
+	if err != nil {
+		return &ScaffoldError{Code: ErrCodeWrite, Step: StepWrite, Path: stagingRoot, Err: err}
+	}
+	defer os.RemoveAll(staging)
+
+	staged := make([]string, len(p.Changes))
+	for i, change := range p.Changes {
+		if change.After == nil {
+			continue
+		}
+		staged[i] = filepath.Join(staging, "new-"+strconv.Itoa(i))
+		if err := os.WriteFile(staged[i], change.After, 0644); err != nil {
+			return &ScaffoldError{Code: ErrCodeWrite, Step: StepWrite, Path: change.Path, Err: err}
+		}
+	}
+
+	backups := make([]string, len(p.Changes))
+	committed := 0
+	rollback := func() {
+		for i := committed - 1; i >= 0; i-- {
+			change := p.Changes[i]
+			if change.After != nil {
+				os.Remove(change.Path)
+			}
+			if backups[i] != "" {
+				os.Rename(backups[i], change.Path)
+			}
+			os.Remove(filepath.Dir(change.Path))
+		}
+	}
+
+	for i, change := range p.Changes {
+		if change.Before != nil {
+			backups[i] = filepath.Join(staging, "old-"+strconv.Itoa(i))
+			if err := os.Rename(change.Path, backups[i]); err != nil {
+				rollback()
+				return &ScaffoldError{Code: ErrCodeWrite, Step: StepWrite, Path: change.Path, Err: err}
+			}
+		}
+		committed = i + 1
+
+		if change.After == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(change.Path), os.ModePerm); err != nil {
+			rollback()
+			return &ScaffoldError{Code: ErrCodeWrite, Step: StepWrite, Path: change.Path, Err: err}
+		}
+		if err := os.Rename(staged[i], change.Path); err != nil {
+			rollback()
+			return &ScaffoldError{Code: ErrCodeWrite, Step: StepWrite, Path: change.Path, Err: err}
+		}
+	}
+
+	for _, change := range p.Changes {
+		switch {
+		case change.After == nil:
+			// Drop directories the scaffold emptied, such as its view directory.
+			os.Remove(filepath.Dir(change.Path))
+			fmt.Printf("%s%sREMOVED%s\t%s\n", Bold, Red, Reset, change.Path)
+		case change.Before == nil:
+			fmt.Printf("%s%sGENERATED%s\t%s\n", Bold, Green, Reset, change.Path)
+		default:
+			fmt.Printf("%s%sUPDATED%s\t%s\n", Bold, Yellow, Reset, change.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeConflictError reports generated files whose hand edits overlap the
+// changes regenerating them makes.
+type MergeConflictError struct {
+	Model string
+	Files []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%s has hand edits that conflict with the regenerated files: %s", e.Model, strings.Join(e.Files, ", "))
+}
+
+// UpdateModel regenerates the scaffold tableName for fields, merging in hand
+// edits. Unless force is set, it refuses when they conflict.
+func UpdateModel(tableName string, fields []Field, force bool) error {
+	plan, err := PlanUpdate(tableName, fields, force)
+	if err != nil {
+		return err
+	}
+	if err := plan.Apply(); err != nil {
+		return err
+	}
+	if err := snapshotScaffold(plan.Model, plan.Generated); err != nil {
+		return stepError(ErrCodeWrite, StepSnapshot, snapshotDir, fmt.Errorf("failed to record generated files: %w", err))
+	}
+	return nil
+}
+
+// PlanUpdate renders the changes UpdateModel makes for tableName in memory.
+func PlanUpdate(tableName string, fields []Field, force bool) (*ScaffoldPlan, error) {
+	if err := LoadProjectConfig(); err != nil {
+		return nil, stepError(ErrCodeInvalidRequest, StepCheck, configFilePath, err)
+	}
+	names := NewScaffoldNames(normalizeName(tableName))
+
+	models, err := ReadModelsFromJSON()
+	if err != nil {
+		return nil, stepError(ErrCodeGenerate, StepModelsJSON, jsonFilePath, err)
+	}
+	key, schema, ok := models.lookup(names)
+	if !ok {
+		return nil, stepError(ErrCodeInvalidRequest, StepCheck, jsonFilePath, fmt.Errorf("there is no %s scaffold to update", names.Model))
+	}
+	fields, err = ValidateFields(fields, schema.Associations)
+	if err != nil {
+		return nil, stepError(ErrCodeInvalidRequest, StepCheck, "", err)
+	}
+
+	plan := &ScaffoldPlan{Model: names.Model}
+	data, err := newScaffoldTemplateData(names, fields, schema.Associations)
+	if err != nil {
+		return nil, stepError(ErrCodeGenerate, StepCheck, "go.mod", err)
+	}
+	files, err := renderScaffoldFiles(names, data)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range names.Files() {
+		if err := planMerge(plan, path, files[path], force); err != nil {
+			return nil, stepError(ErrCodeGenerate, StepMerge, path, err)
+		}
+	}
+
+	schema.Fields = fields
+	if key != names.Model {
+		// Entries under legacy keys move to the model name.
+		if err := planModelJSONRemoval(plan, key); err != nil {
+			return nil, stepError(ErrCodeGenerate, StepModelsJSON, jsonFilePath, err)
+		}
+	}
+	if err := planModelJSON(plan, names.Model, schema); err != nil {
+		return nil, stepError(ErrCodeGenerate, StepModelsJSON, jsonFilePath, fmt.Errorf("failed to update models.json: %w", err))
+	}
+	if err := planSchemaMigration(plan, "update_"+names.ViewDir); err != nil {
+		return nil, stepError(ErrCodeGenerate, StepMigration, migrationsDir, fmt.Errorf("failed to write SQL migration: %w", err))
+	}
+	if err := planModuleRequirements(plan, fields); err != nil {
+		return nil, stepError(ErrCodeGenerate, StepCheck, "go.mod", fmt.Errorf("failed to update go.mod: %w", err))
+	}
+	return plan, nil
+}
+
+// planMerge plans path to hold generated merged with its hand edits.
+func planMerge(plan *ScaffoldPlan, path string, generated []byte, force bool) error {
+	base, err := readSnapshot(plan.Model, path)
+	if err != nil {
+		return err
+	}
+	current, err := plan.current(path)
+	if err != nil {
+		return err
+	}
+
+	merged := generated
+	if current != nil {
+		var clean bool
+		if merged, clean = mergeFile(path, base, current, generated); !clean {
+			if force {
+				merged = generated
+			} else {
+				plan.Conflicts = append(plan.Conflicts, path)
+			}
+		}
+	}
+	if err := plan.write(path, merged); err != nil {
+		return err
+	}
+	if plan.Generated == nil {
+		plan.Generated = map[string][]byte{}
+	}
+	plan.Generated[path] = generated
+	return nil
+}
@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPlanUpdateLegacyKey(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	if err := os.WriteFile(jsonFilePath, []byte(`{"users": [{"name": "Name", "type": "string"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanUpdate("users", []Field{{Name: "Name", Type: "string"}, {Name: "Email", Type: "string"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change := plan.find(jsonFilePath)
+	if change == nil {
+		t.Fatal("models.json is not updated")
+	}
+	models, err := parseModelsJSON(change.After)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := models["users"]; ok || len(models["Users"].Fields) != 2 {
+		t.Errorf("models.json is not moved to the model name: %s", change.After)
+	}
+}
@@ -0,0 +1,443 @@
+package helpers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of a SchemaIssue, shared with the /dev page.
+const (
+	IssueMissingTable  = "missing_table"
+	IssueOrphanTable   = "orphan_table"
+	IssueMissingStruct = "missing_struct"
+	IssueUntracked     = "untracked_model"
+	IssueMissingColumn = "missing_column"
+	IssueColumnDiffers = "column_mismatch"
+)
+
+// Where a schema is read from, as named in SchemaIssue messages.
+const (
+	sourceModelsJSON = "models.json"
+	sourceStructs    = "models/"
+	sourceDatabase   = "database"
+)
+
+// SchemaIssue is one way models.json, the structs in models/ and the
+// database disagree.
+type SchemaIssue struct {
+	Kind    string `json:"kind"`
+	Table   string `json:"table"`
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// SchemaReport lists how models.json, the structs in models/ and the
+// database drifted apart.
+type SchemaReport struct {
+	Issues []SchemaIssue `json:"issues"`
+	// Reconcile holds the statements that would migrate the database to
+	// what models.json describes.
+	Reconcile []string `json:"reconcile"`
+}
+
+// gormSettings parses the gorm tag of a struct field into its settings, keyed
+// by lower case name. Settings without a value map to "".
+func gormSettings(tag *ast.BasicLit) map[string]string {
+	settings := map[string]string{}
+	if tag == nil {
+		return settings
+	}
+	raw, err := strconv.Unquote(tag.Value)
+	if err != nil {
+		return settings
+	}
+	for _, setting := range strings.Split(reflect.StructTag(raw).Get("gorm"), ";") {
+		key, value, _ := strings.Cut(setting, ":")
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			settings[key] = strings.TrimSpace(value)
+		}
+	}
+	return settings
+}
+
+// structTableNames returns the table names TableName methods with a constant
+// result give to the structs of file.
+func structTableNames(file *ast.File) map[string]string {
+	names := map[string]string{}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "TableName" || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Body == nil || len(fn.Body.List) != 1 {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		ident, ok := recv.(*ast.Ident)
+		ret, isReturn := fn.Body.List[0].(*ast.ReturnStmt)
+		if !ok || !isReturn || len(ret.Results) != 1 {
+			continue
+		}
+		if lit, ok := ret.Results[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+			if name, err := strconv.Unquote(lit.Value); err == nil {
+				names[ident.Name] = name
+			}
+		}
+	}
+	return names
+}
+
+// structSchema returns the tables the structs in models/ map to once plan is
+// applied.
+func structSchema(plan *ScaffoldPlan) (sqlSchema, map[string]string, error) {
+	paths, err := modelFiles(plan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type modelStruct struct {
+		name  string
+		table string
+		st    *ast.StructType
+	}
+	var structs []modelStruct
+	isModel := map[string]bool{}
+	for _, path := range paths {
+		content, err := plan.current(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, content, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		tableNames := structTableNames(file)
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				st, ok := typeSpec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				table := tableNames[typeSpec.Name.Name]
+				if table == "" {
+					table = tableName(typeSpec.Name.Name)
+				}
+				structs = append(structs, modelStruct{typeSpec.Name.Name, table, st})
+				isModel[typeSpec.Name.Name] = true
+			}
+		}
+	}
+
+	schema := sqlSchema{}
+	structNames := map[string]string{}
+	for _, model := range structs {
+		table := &sqlTable{Name: model.table}
+		for _, field := range model.st.Fields.List {
+			fieldType := types.ExprString(field.Type)
+			if len(field.Names) == 0 {
+				if fieldType == "gorm.Model" {
+					table.Columns = append(table.Columns, gormModelSQLColumns()...)
+					table.PrimaryKey = []string{"id"}
+					table.Indexes = append(table.Indexes, sqlIndex{Name: "idx_" + table.Name + "_deleted_at", Columns: []string{"deleted_at"}})
+				}
+				continue
+			}
+			elem := strings.TrimPrefix(strings.TrimPrefix(fieldType, "[]"), "*")
+			settings := gormSettings(field.Tag)
+			if _, ok := settings["-"]; ok || isModel[elem] || !ast.IsExported(field.Names[0].Name) {
+				continue
+			}
+			if strings.HasPrefix(fieldType, "[]") && fieldType != "[]byte" {
+				continue
+			}
+
+			for _, name := range field.Names {
+				table.addStructField(name.Name, fieldType, settings)
+			}
+		}
+		if len(table.Columns) == 0 {
+			continue
+		}
+		schema[table.Name] = table
+		structNames[table.Name] = model.name
+	}
+	return schema, structNames, nil
+}
+
+// addStructField adds the column of a struct field of type fieldType with the
+// gorm tag settings.
+func (t *sqlTable) addStructField(name, fieldType string, settings map[string]string) {
+	_, notNull := settings["not null"]
+	_, primaryKey := settings["primarykey"]
+	_, unique := settings["unique"]
+	field := Field{
+		Name:     name,
+		Type:     fieldType,
+		Nullable: !notNull && !primaryKey,
+		Unique:   unique,
+		Default:  settings["default"],
+		Comment:  settings["comment"],
+	}
+	field.Size, _ = strconv.Atoi(settings["size"])
+	field.Precision, _ = strconv.Atoi(settings["precision"])
+	field.Scale, _ = strconv.Atoi(settings["scale"])
+	if _, ok := settings["uniqueindex"]; ok {
+		field.Unique = true
+	}
+	_, indexed := settings["index"]
+	field.Index = indexed
+
+	column := fieldColumn(field)
+	if settings["column"] != "" {
+		column.Name = settings["column"]
+	}
+	if settings["type"] != "" {
+		column.Type = normalizeColumnType(settings["type"])
+	}
+	if name == "ID" && len(t.PrimaryKey) == 0 && !primaryKey {
+		// gorm makes an ID field the auto-incremented primary key.
+		primaryKey = true
+		column.Null = false
+		column.AutoIncrement = true
+	}
+	if primaryKey {
+		t.PrimaryKey = append(t.PrimaryKey, column.Name)
+	}
+	if indexed {
+		index := settings["index"]
+		if index == "" {
+			index = "idx_" + t.Name + "_" + column.Name
+		}
+		t.Indexes = append(t.Indexes, sqlIndex{Name: index, Columns: []string{column.Name}})
+	}
+	t.addColumn(column)
+}
+
+// schemaSource is a schema and where it was read from.
+type schemaSource struct {
+	name   string
+	schema sqlSchema
+}
+
+// claims reports whether the source describes all of table, rather than the
+// association columns models.json adds to tables it does not own.
+func (s schemaSource) claims(table string) bool {
+	return s.schema[table] != nil && !s.schema[table].External
+}
+
+// joinSources lists the names of sources as "a, b and c".
+func joinSources(sources []schemaSource) string {
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.name
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// compareSchemas reports the tables and columns the sources disagree on.
+func compareSchemas(jsonSource, structSource, dbSource schemaSource, structNames map[string]string) []SchemaIssue {
+	sources := []schemaSource{jsonSource, structSource, dbSource}
+	tables := map[string]bool{}
+	for _, source := range sources {
+		for name := range source.schema {
+			tables[name] = true
+		}
+	}
+	delete(tables, schemaMigrationsTable)
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []SchemaIssue
+	for _, name := range names {
+		var present []schemaSource
+		for _, source := range sources {
+			if source.claims(name) {
+				present = append(present, source)
+			}
+		}
+
+		inJSON, inStructs, inDatabase := jsonSource.claims(name), structSource.claims(name), dbSource.claims(name)
+		switch {
+		case !inDatabase && (inJSON || inStructs):
+			issues = append(issues, SchemaIssue{Kind: IssueMissingTable, Table: name,
+				Message: fmt.Sprintf("%s is in %s but not in the database", name, joinSources(present))})
+		case inDatabase && !inJSON && !inStructs && jsonSource.schema[name] == nil:
+			issues = append(issues, SchemaIssue{Kind: IssueOrphanTable, Table: name,
+				Message: fmt.Sprintf("%s is in the database but no model maps to it", name)})
+		}
+		if inJSON && !inStructs {
+			issues = append(issues, SchemaIssue{Kind: IssueMissingStruct, Table: name,
+				Message: fmt.Sprintf("%s is in models.json but no struct in models/ maps to it", name)})
+		}
+		if inStructs && !inJSON && jsonSource.schema[name] == nil {
+			issues = append(issues, SchemaIssue{Kind: IssueUntracked, Table: name,
+				Message: fmt.Sprintf("%s in models/ has no models.json entry", structNames[name])})
+		}
+
+		// Tables only models.json has an entry for still have the association
+		// columns it adds to compare.
+		compared := present
+		if !inJSON && jsonSource.schema[name] != nil {
+			compared = append(compared, jsonSource)
+		}
+		if len(compared) > 1 {
+			issues = append(issues, compareColumns(name, compared)...)
+		}
+	}
+	return issues
+}
+
+// compareColumns reports the columns of table that sources lack or define
+// differently. External tables only count the columns they declare.
+func compareColumns(table string, sources []schemaSource) []SchemaIssue {
+	var columns []string
+	seen := map[string]bool{}
+	for _, source := range sources {
+		for _, column := range source.schema[table].Columns {
+			if !seen[column.Name] {
+				seen[column.Name] = true
+				columns = append(columns, column.Name)
+			}
+		}
+	}
+
+	var issues []SchemaIssue
+	for _, name := range columns {
+		var having, lacking []schemaSource
+		var definitions, described []string
+		for _, source := range sources {
+			column := source.schema[table].column(name)
+			switch {
+			case column != nil:
+				having = append(having, source)
+				definitions = append(definitions, column.Definition())
+				described = append(described, column.Definition()+" in "+source.name)
+			case !source.schema[table].External:
+				lacking = append(lacking, source)
+			}
+		}
+		if len(lacking) > 0 {
+			issues = append(issues, SchemaIssue{Kind: IssueMissingColumn, Table: table, Column: name,
+				Message: fmt.Sprintf("%s.%s is in %s but not in %s", table, name, joinSources(having), joinSources(lacking))})
+			continue
+		}
+		for _, definition := range definitions[1:] {
+			if definition != definitions[0] {
+				issues = append(issues, SchemaIssue{Kind: IssueColumnDiffers, Table: table, Column: name,
+					Message: fmt.Sprintf("%s.%s is %s", table, name, strings.Join(described, ", "))})
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// reconcileSchemas restricts database to what migrating it to expected
+// touches.
+func reconcileSchemas(database, expected sqlSchema) sqlSchema {
+	current := sqlSchema{}
+	for name, table := range expected {
+		actual := database[name]
+		if actual == nil {
+			continue
+		}
+		if !table.External {
+			current[name] = actual
+			continue
+		}
+		restricted := &sqlTable{Name: name, External: true}
+		for _, column := range actual.Columns {
+			if table.column(column.Name) != nil {
+				restricted.Columns = append(restricted.Columns, column)
+			}
+		}
+		for _, index := range actual.Indexes {
+			if hasIndex(table, index) {
+				restricted.Indexes = append(restricted.Indexes, index)
+			}
+		}
+		for _, key := range actual.ForeignKeys {
+			if hasForeignKey(table, key) {
+				restricted.ForeignKeys = append(restricted.ForeignKeys, key)
+			}
+		}
+		current[name] = restricted
+	}
+	return current
+}
+
+// schemasToCompare reads the schema of models.json, models/ and the database.
+func schemasToCompare(db *gorm.DB) (jsonSource, structSource, dbSource schemaSource, structNames map[string]string, err error) {
+	models, err := ReadModelsFromJSON()
+	if err != nil {
+		return
+	}
+	structs, structNames, err := structSchema(&ScaffoldPlan{})
+	if err != nil {
+		return
+	}
+	database, err := databaseSchema(db)
+	if err != nil {
+		return
+	}
+	return schemaSource{sourceModelsJSON, schemaOf(models)}, schemaSource{sourceStructs, structs},
+		schemaSource{sourceDatabase, database}, structNames, nil
+}
+
+// DiagnoseSchema compares models.json, the structs in models/ and the tables
+// of the database db is connected to.
+func DiagnoseSchema(db *gorm.DB) (*SchemaReport, error) {
+	if err := LoadProjectConfig(); err != nil {
+		return nil, err
+	}
+	jsonSource, structSource, dbSource, structNames, err := schemasToCompare(db)
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaReport{
+		Issues:    compareSchemas(jsonSource, structSource, dbSource, structNames),
+		Reconcile: diffSchemas(reconcileSchemas(dbSource.schema, jsonSource.schema), jsonSource.schema),
+	}, nil
+}
+
+// PlanSchemaReconcile plans a migration bringing the database to what
+// models.json describes, dropping columns it does not list.
+func PlanSchemaReconcile(db *gorm.DB) (*ScaffoldPlan, error) {
+	if err := LoadProjectConfig(); err != nil {
+		return nil, err
+	}
+	jsonSource, _, dbSource, _, err := schemasToCompare(db)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ScaffoldPlan{}
+	migrations, err := plannedMigrations(plan)
+	if err != nil {
+		return nil, err
+	}
+	current := reconcileSchemas(dbSource.schema, jsonSource.schema)
+	if err := planMigrationFiles(plan, migrations, "reconcile_schema", current, jsonSource.schema); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,219 @@
+package helpers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// issueKeys lists issues as "kind table.column" for comparison.
+func issueKeys(issues []SchemaIssue) []string {
+	keys := []string{}
+	for _, issue := range issues {
+		key := issue.Kind + " " + issue.Table
+		if issue.Column != "" {
+			key += "." + issue.Column
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestStructSchemaMatchesScaffold(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	writeModels(t, map[string]string{
+		"user.go": "package models\n\nimport \"gorm.io/gorm\"\n\ntype User struct {\n\tgorm.Model\n\tName string `gorm:\"size:100;not null\"`\n}\n",
+	})
+	plan, err := PlanModel("product", []Field{
+		{Name: "name", Type: "string", Size: 100, Unique: true},
+		{Name: "stock", Type: "int", Nullable: true, Index: true},
+		{Name: "price", Type: "decimal", Precision: 10, Scale: 2},
+		{Name: "status", Type: "enum", Values: []string{"draft", "published"}, Default: "draft"},
+		{Name: "active", Type: "bool", Default: "true"},
+		{Name: "released", Type: "time.Time", Comment: "first sale"},
+	}, []Association{{Kind: BelongsTo, Model: "user"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := plan.current(jsonFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	models, err := parseModelsJSON(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs, structNames, err := structSchema(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if structNames["products"] != "Product" || structNames["users"] != "User" {
+		t.Errorf("struct names %v", structNames)
+	}
+
+	// The database is migrated to models.json.
+	jsonSchema := schemaOf(models)
+	issues := compareSchemas(schemaSource{sourceModelsJSON, jsonSchema}, schemaSource{sourceStructs, structs},
+		schemaSource{sourceDatabase, jsonSchema}, structNames)
+	want := []string{IssueMissingTable + " users", IssueUntracked + " users"}
+	if got := issueKeys(issues); !reflect.DeepEqual(got, want) {
+		t.Errorf("generated models drift from models.json: %v", issues)
+	}
+}
+
+func TestStructSchema(t *testing.T) {
+	chdirTemp(t)
+	writeModels(t, map[string]string{
+		"person.go": "package models\n\n" +
+			"type Person struct {\n" +
+			"\tID       uint\n" +
+			"\tNickName string `gorm:\"column:nick;size:40;uniqueIndex\"`\n" +
+			"\tAge      *int   `gorm:\"index:idx_age\"`\n" +
+			"\tScore    int32  `gorm:\"type:int(11) unsigned;not null\"`\n" +
+			"\tSecret   string `gorm:\"-\"`\n" +
+			"\tPets     []Pet\n" +
+			"\tnote     string\n" +
+			"}\n\n" +
+			"func (Person) TableName() string {\n\treturn \"people\"\n}\n\n" +
+			"type Pet struct {\n\tID       uint\n\tPersonID uint\n}\n",
+	})
+	schema, structNames, err := structSchema(&ScaffoldPlan{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &sqlTable{
+		Name: "people",
+		Columns: []sqlColumn{
+			{Name: "id", Type: "bigint unsigned", AutoIncrement: true},
+			{Name: "nick", Type: "varchar(40)", Null: true, Unique: true},
+			{Name: "age", Type: "bigint", Null: true},
+			{Name: "score", Type: "int unsigned"},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes:    []sqlIndex{{Name: "idx_age", Columns: []string{"age"}}},
+	}
+	if got := schema["people"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("people is\n%+v\nwant\n%+v", got, want)
+	}
+	if schema["pets"] == nil || structNames["people"] != "Person" || structNames["pets"] != "Pet" {
+		t.Errorf("schema %v, struct names %v", schema, structNames)
+	}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	products := func(columns ...sqlColumn) *sqlTable {
+		return &sqlTable{Name: "products", Columns: append([]sqlColumn{{Name: "id", Type: "bigint unsigned", AutoIncrement: true}}, columns...), PrimaryKey: []string{"id"}}
+	}
+	name := sqlColumn{Name: "name", Type: "varchar(100)"}
+	tests := []struct {
+		name                    string
+		json, structs, database sqlSchema
+		want                    []string
+	}{
+		{
+			name:     "in sync",
+			json:     sqlSchema{"products": products(name)},
+			structs:  sqlSchema{"products": products(name)},
+			database: sqlSchema{"products": products(name), schemaMigrationsTable: {Name: schemaMigrationsTable}},
+			want:     []string{},
+		},
+		{
+			name:     "table not migrated",
+			json:     sqlSchema{"products": products(name)},
+			structs:  sqlSchema{"products": products(name)},
+			database: sqlSchema{},
+			want:     []string{IssueMissingTable + " products"},
+		},
+		{
+			name:     "table without a model",
+			json:     sqlSchema{},
+			structs:  sqlSchema{},
+			database: sqlSchema{"legacy": {Name: "legacy"}},
+			want:     []string{IssueOrphanTable + " legacy"},
+		},
+		{
+			name:     "struct deleted",
+			json:     sqlSchema{"products": products(name)},
+			structs:  sqlSchema{},
+			database: sqlSchema{"products": products(name)},
+			want:     []string{IssueMissingStruct + " products"},
+		},
+		{
+			name:     "struct written by hand",
+			json:     sqlSchema{},
+			structs:  sqlSchema{"products": products(name)},
+			database: sqlSchema{"products": products(name)},
+			want:     []string{IssueUntracked + " products"},
+		},
+		{
+			name:     "column missing and differing",
+			json:     sqlSchema{"products": products(name, sqlColumn{Name: "stock", Type: "bigint", Null: true})},
+			structs:  sqlSchema{"products": products(sqlColumn{Name: "name", Type: "longtext"}, sqlColumn{Name: "stock", Type: "bigint", Null: true})},
+			database: sqlSchema{"products": products(name)},
+			want:     []string{IssueColumnDiffers + " products.name", IssueMissingColumn + " products.stock"},
+		},
+		{
+			name: "association column of a table models.json does not own",
+			json: sqlSchema{"comments": {Name: "comments", External: true, Columns: []sqlColumn{foreignKeyColumn("product_id")}}},
+			structs: sqlSchema{"comments": {Name: "comments", Columns: []sqlColumn{
+				{Name: "id", Type: "bigint unsigned", AutoIncrement: true},
+			}}},
+			database: sqlSchema{"comments": {Name: "comments", Columns: []sqlColumn{
+				{Name: "id", Type: "bigint unsigned", AutoIncrement: true},
+			}}},
+			want: []string{IssueMissingColumn + " comments.product_id"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues := compareSchemas(schemaSource{sourceModelsJSON, test.json}, schemaSource{sourceStructs, test.structs},
+				schemaSource{sourceDatabase, test.database}, map[string]string{"products": "Product", "comments": "Comment"})
+			if got := issueKeys(issues); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", issues, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildDatabaseSchema(t *testing.T) {
+	defaultDraft, defaultNow, defaultNull := "draft", "CURRENT_TIMESTAMP(3)", "NULL"
+	columns := []informationSchemaColumn{
+		{Table: "posts", Name: "id", ColumnType: "bigint unsigned", IsNullable: "NO", Extra: "auto_increment"},
+		{Table: "posts", Name: "views", ColumnType: "int(11)", IsNullable: "NO"},
+		{Table: "posts", Name: "published", ColumnType: "tinyint(1)", IsNullable: "NO"},
+		{Table: "posts", Name: "status", ColumnType: "varchar(20)", IsNullable: "NO", Default: &defaultDraft},
+		{Table: "posts", Name: "created_at", ColumnType: "datetime(3)", IsNullable: "YES", Default: &defaultNow, Extra: "DEFAULT_GENERATED"},
+		{Table: "posts", Name: "slug", ColumnType: "varchar(100)", IsNullable: "YES", Default: &defaultNull, Comment: "url part"},
+		{Table: "posts", Name: "user_id", ColumnType: "bigint unsigned", IsNullable: "YES"},
+	}
+	indexes := []informationSchemaIndex{
+		{Table: "posts", Name: "PRIMARY", Column: "id"},
+		{Table: "posts", Name: "fk_posts_user", Column: "user_id", NonUnique: 1},
+		{Table: "posts", Name: "idx_status_views", Column: "status", NonUnique: 1},
+		{Table: "posts", Name: "idx_status_views", Column: "views", NonUnique: 1},
+		{Table: "posts", Name: "slug", Column: "slug"},
+	}
+	foreignKeys := []informationSchemaForeignKey{
+		{Table: "posts", Name: "fk_posts_user", Column: "user_id", RefTable: "users", RefColumn: "id"},
+	}
+
+	want := sqlSchema{"posts": {
+		Name: "posts",
+		Columns: []sqlColumn{
+			{Name: "id", Type: "bigint unsigned", AutoIncrement: true},
+			{Name: "views", Type: "int"},
+			{Name: "published", Type: "boolean"},
+			{Name: "status", Type: "varchar(20)", Default: "'draft'"},
+			{Name: "created_at", Type: "datetime(3)", Null: true, Default: "CURRENT_TIMESTAMP(3)"},
+			{Name: "slug", Type: "varchar(100)", Null: true, Unique: true, Comment: "url part"},
+			{Name: "user_id", Type: "bigint unsigned", Null: true},
+		},
+		PrimaryKey:  []string{"id"},
+		Indexes:     []sqlIndex{{Name: "idx_status_views", Columns: []string{"status", "views"}}},
+		ForeignKeys: []sqlForeignKey{{Name: "fk_posts_user", Column: "user_id", RefTable: "users", RefColumn: "id"}},
+	}}
+	if got := buildDatabaseSchema(columns, indexes, foreignKeys); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got["posts"], want["posts"])
+	}
+}
@@ -0,0 +1,438 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sqlColumn is a column as the SQL migrations declare it.
+type sqlColumn struct {
+	Name          string
+	Type          string // such as "varchar(100)" or "bigint unsigned"
+	Null          bool
+	Unique        bool
+	Default       string // as an SQL expression, empty for none
+	Comment       string
+	AutoIncrement bool
+}
+
+// Definition renders everything after the column name, such as
+// "varchar(100) NOT NULL UNIQUE".
+func (c sqlColumn) Definition() string {
+	parts := []string{c.Type}
+	if c.Null {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.AutoIncrement {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+	if c.Unique {
+		parts = append(parts, "UNIQUE")
+	}
+	if c.Default != "" {
+		parts = append(parts, "DEFAULT "+c.Default)
+	}
+	if c.Comment != "" {
+		parts = append(parts, "COMMENT "+sqlLiteral(c.Comment))
+	}
+	return strings.Join(parts, " ")
+}
+
+// sqlIndex is a secondary index of a table.
+type sqlIndex struct {
+	Name    string
+	Columns []string
+}
+
+// sqlForeignKey is a foreign key constraint of a table.
+type sqlForeignKey struct {
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// sqlTable is a table as the SQL migrations create it. External tables are
+// never created or dropped, only their association columns.
+type sqlTable struct {
+	Name        string
+	Columns     []sqlColumn
+	PrimaryKey  []string
+	Indexes     []sqlIndex
+	ForeignKeys []sqlForeignKey
+	External    bool
+}
+
+// sqlSchema is the database models.json describes, keyed by table name.
+type sqlSchema map[string]*sqlTable
+
+// tableName is the table gorm stores model in.
+func tableName(model string) string {
+	return NewScaffoldNames(ToSnakeCase(model)).ViewDir
+}
+
+func (t *sqlTable) column(name string) *sqlColumn {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func (t *sqlTable) addColumn(column sqlColumn) {
+	if t.column(column.Name) == nil {
+		t.Columns = append(t.Columns, column)
+	}
+}
+
+// table returns the table name of schema, adding an external one if missing.
+func (s sqlSchema) table(name string) *sqlTable {
+	if s[name] == nil {
+		s[name] = &sqlTable{Name: name, External: true}
+	}
+	return s[name]
+}
+
+// sqlDataType returns the MySQL type gorm's migrator picks for field, so SQL
+// migrations and the generated models agree.
+func sqlDataType(field Field) string {
+	if field.SQLType != "" {
+		return field.SQLType
+	}
+	if isEnum(field) {
+		// Written as MySQL reports the column type, so doctor finds no drift.
+		return "enum('" + strings.Join(field.Values, "','") + "')"
+	}
+	if sqlType := fieldTypeOf(field).SQLType; sqlType != "" {
+		return strings.ToLower(sqlType)
+	}
+	goType := strings.TrimPrefix(field.Type, "*")
+	switch goType {
+	case "bool":
+		return "boolean"
+	case "int", "int64", "uint", "uint64":
+		return unsigned("bigint", goType)
+	case "int32", "uint32":
+		return unsigned("int", goType)
+	case "int16", "uint16":
+		return unsigned("smallint", goType)
+	case "int8", "uint8":
+		return unsigned("tinyint", goType)
+	case "float32", "float64", "decimal.Decimal":
+		if field.Precision > 0 {
+			return fmt.Sprintf("decimal(%d,%d)", field.Precision, field.Scale)
+		}
+		if goType == "float32" {
+			return "float"
+		}
+		if goType == "decimal.Decimal" {
+			return "decimal(10,2)"
+		}
+		return "double"
+	case "time.Time":
+		return "datetime(3)"
+	case "datatypes.JSON":
+		return "json"
+	case "[]byte":
+		if field.Size > 0 && field.Size < 65536 {
+			return fmt.Sprintf("varbinary(%d)", field.Size)
+		}
+		return "longblob"
+	}
+
+	size := field.Size
+	if size == 0 && (field.Unique || field.Index) {
+		// Indexed text needs a length; gorm uses the longest utf8mb4 key.
+		size = 191
+	}
+	switch {
+	case size > 0 && size < 65536:
+		return fmt.Sprintf("varchar(%d)", size)
+	case size >= 65536 && size < 1<<24:
+		return "mediumtext"
+	}
+	return "longtext"
+}
+
+func unsigned(sqlType, goType string) string {
+	if strings.HasPrefix(goType, "uint") {
+		return sqlType + " unsigned"
+	}
+	return sqlType
+}
+
+// sqlLiteral quotes value as an SQL string.
+func sqlLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// sqlDefault returns the default of field as an SQL expression. Numbers and
+// booleans are written bare once they parse, anything else is quoted.
+func sqlDefault(field Field) (string, error) {
+	goType := strings.TrimPrefix(field.Type, "*")
+	switch {
+	case strings.HasPrefix(goType, "uint"):
+		_, err := strconv.ParseUint(field.Default, 10, 64)
+		return field.Default, err
+	case isIntegerType(goType):
+		return field.Default, checkInt(field.Default)
+	case isDecimalType(goType):
+		return field.Default, checkFloat(field.Default)
+	case goType == "bool":
+		// Written as MySQL reports boolean defaults.
+		value, err := strconv.ParseBool(field.Default)
+		if value {
+			return "1", err
+		}
+		return "0", err
+	}
+	return sqlLiteral(field.Default), nil
+}
+
+// fieldColumn returns the column field is stored in.
+func fieldColumn(field Field) sqlColumn {
+	column := sqlColumn{
+		Name:    ToSnakeCase(field.Name),
+		Type:    sqlDataType(field),
+		Null:    isNullable(field),
+		Unique:  field.Unique,
+		Comment: field.Comment,
+	}
+	if field.Default != "" {
+		// Scaffolds refuse invalid defaults; one edited into models.json is
+		// left out rather than break the migration.
+		if value, err := sqlDefault(field); err == nil {
+			column.Default = value
+		}
+	}
+	return column
+}
+
+// gormModelSQLColumns are the columns every table gets from gorm.Model.
+func gormModelSQLColumns() []sqlColumn {
+	return []sqlColumn{
+		{Name: "id", Type: "bigint unsigned", AutoIncrement: true},
+		{Name: "created_at", Type: "datetime(3)", Null: true},
+		{Name: "updated_at", Type: "datetime(3)", Null: true},
+		{Name: "deleted_at", Type: "datetime(3)", Null: true},
+	}
+}
+
+// foreignKeyColumn is a column holding the ID of another table.
+func foreignKeyColumn(name string) sqlColumn {
+	return sqlColumn{Name: name, Type: "bigint unsigned", Null: true}
+}
+
+// schemaOf returns the tables models.json describes, including association
+// columns and join tables.
+func schemaOf(models ModelsJSON) sqlSchema {
+	schema := sqlSchema{}
+	modelNames := make([]string, 0, len(models))
+	for name := range models {
+		modelNames = append(modelNames, name)
+	}
+	sort.Strings(modelNames)
+
+	for _, name := range modelNames {
+		table := &sqlTable{Name: tableName(name), PrimaryKey: []string{"id"}}
+		if existing := schema[table.Name]; existing != nil {
+			// Back references may have declared it external already.
+			table.Columns = existing.Columns
+			table.ForeignKeys = existing.ForeignKeys
+		}
+		columns := table.Columns
+		table.Columns = gormModelSQLColumns()
+		table.Indexes = []sqlIndex{{Name: "idx_" + table.Name + "_deleted_at", Columns: []string{"deleted_at"}}}
+		for _, field := range models[name].Fields {
+			column := fieldColumn(field)
+			table.addColumn(column)
+			if field.Index {
+				table.Indexes = append(table.Indexes, sqlIndex{Name: "idx_" + table.Name + "_" + column.Name, Columns: []string{column.Name}})
+			}
+		}
+		for _, column := range columns {
+			table.addColumn(column)
+		}
+		schema[table.Name] = table
+
+		names := NewScaffoldNames(ToSnakeCase(name))
+		for _, association := range models[name].Associations {
+			other := NewScaffoldNames(association.Model)
+			switch association.Kind {
+			case BelongsTo:
+				column := other.Table + "_id"
+				table.addColumn(foreignKeyColumn(column))
+				table.ForeignKeys = append(table.ForeignKeys, sqlForeignKey{
+					Name: "fk_" + table.Name + "_" + other.Table, Column: column,
+					RefTable: other.ViewDir, RefColumn: "id",
+				})
+			case HasMany:
+				column := names.Table + "_id"
+				otherTable := schema.table(other.ViewDir)
+				otherTable.addColumn(foreignKeyColumn(column))
+				otherTable.ForeignKeys = append(otherTable.ForeignKeys, sqlForeignKey{
+					Name: "fk_" + table.Name + "_" + other.ViewDir, Column: column,
+					RefTable: table.Name, RefColumn: "id",
+				})
+			case ManyToMany:
+				join := joinNames(names, other)
+				left, right := names.Table+"_id", other.Table+"_id"
+				schema[join.ViewDir] = &sqlTable{
+					Name: join.ViewDir,
+					Columns: []sqlColumn{
+						{Name: left, Type: "bigint unsigned"},
+						{Name: right, Type: "bigint unsigned"},
+					},
+					PrimaryKey: []string{left, right},
+					ForeignKeys: []sqlForeignKey{
+						{Name: "fk_" + join.ViewDir + "_" + names.Table, Column: left, RefTable: table.Name, RefColumn: "id"},
+						{Name: "fk_" + join.ViewDir + "_" + other.Table, Column: right, RefTable: other.ViewDir, RefColumn: "id"},
+					},
+				}
+			}
+		}
+	}
+	return schema
+}
+
+func quoteIdent(name string) string {
+	return "`" + name + "`"
+}
+
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func createTableSQL(table *sqlTable) string {
+	var lines []string
+	for _, column := range table.Columns {
+		lines = append(lines, "  "+quoteIdent(column.Name)+" "+column.Definition())
+	}
+	if len(table.PrimaryKey) > 0 {
+		lines = append(lines, "  PRIMARY KEY ("+quoteIdents(table.PrimaryKey)+")")
+	}
+	for _, index := range table.Indexes {
+		lines = append(lines, "  INDEX "+quoteIdent(index.Name)+" ("+quoteIdents(index.Columns)+")")
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n);", quoteIdent(table.Name), strings.Join(lines, ",\n"))
+}
+
+func addForeignKeySQL(table string, key sqlForeignKey) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s);",
+		quoteIdent(table), quoteIdent(key.Name), quoteIdent(key.Column), quoteIdent(key.RefTable), quoteIdent(key.RefColumn))
+}
+
+func sortedTableNames(schema sqlSchema) []string {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// diffSchemas returns the statements migrating a database from before to
+// after.
+func diffSchemas(before, after sqlSchema) []string {
+	var drops, creates, alters, removals, adds []string
+
+	for _, name := range sortedTableNames(before) {
+		old, new := before[name], after[name]
+		for _, key := range old.ForeignKeys {
+			if new == nil || !hasForeignKey(new, key) {
+				drops = append(drops, fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s;", quoteIdent(name), quoteIdent(key.Name)))
+			}
+		}
+		switch {
+		case !old.External && (new == nil || new.External):
+			removals = append(removals, fmt.Sprintf("DROP TABLE IF EXISTS %s;", quoteIdent(name)))
+		case old.External && new == nil:
+			alters = append(alters, alterTableSQL(old, &sqlTable{Name: name, External: true})...)
+		}
+	}
+
+	for _, name := range sortedTableNames(after) {
+		old, new := before[name], after[name]
+		for _, key := range new.ForeignKeys {
+			if old == nil || !hasForeignKey(old, key) {
+				adds = append(adds, addForeignKeySQL(name, key))
+			}
+		}
+		switch {
+		case !new.External && (old == nil || old.External):
+			creates = append(creates, createTableSQL(new))
+		case new.External && old != nil && !old.External:
+			// Dropped along with the table.
+		default:
+			if old == nil {
+				old = &sqlTable{Name: name, External: true}
+			}
+			alters = append(alters, alterTableSQL(old, new)...)
+		}
+	}
+
+	var statements []string
+	for _, group := range [][]string{drops, creates, alters, removals, adds} {
+		statements = append(statements, group...)
+	}
+	return statements
+}
+
+func hasForeignKey(table *sqlTable, key sqlForeignKey) bool {
+	for _, existing := range table.ForeignKeys {
+		if existing == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasIndex(table *sqlTable, index sqlIndex) bool {
+	for _, existing := range table.Indexes {
+		if existing.Name == index.Name && strings.Join(existing.Columns, ",") == strings.Join(index.Columns, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+// alterTableSQL returns the statements changing the columns and indexes of
+// old into those of new.
+func alterTableSQL(old, new *sqlTable) []string {
+	table := quoteIdent(new.Name)
+	var statements []string
+	for _, index := range old.Indexes {
+		if !hasIndex(new, index) {
+			statements = append(statements, fmt.Sprintf("DROP INDEX %s ON %s;", quoteIdent(index.Name), table))
+		}
+	}
+	for _, column := range new.Columns {
+		existing := old.column(column.Name)
+		switch {
+		case existing == nil:
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, quoteIdent(column.Name), column.Definition()))
+		case existing.Definition() != column.Definition():
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s;", table, quoteIdent(column.Name), column.Definition()))
+		}
+	}
+	for _, column := range old.Columns {
+		if new.column(column.Name) == nil {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, quoteIdent(column.Name)))
+		}
+	}
+	for _, index := range new.Indexes {
+		if !hasIndex(old, index) {
+			statements = append(statements, fmt.Sprintf("CREATE INDEX %s ON %s (%s);", quoteIdent(index.Name), table, quoteIdents(index.Columns)))
+		}
+	}
+	return statements
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFieldColumnNullability(t *testing.T) {
+	for _, field := range []Field{
+		{Name: "Title", Type: "string"},
+		{Name: "Title", Type: "string", Nullable: true},
+		{Name: "Price", Type: "*decimal.Decimal", Kind: "decimal"},
+	} {
+		notNull := strings.Contains(gormTag(field), "not null")
+		if column := fieldColumn(field); column.Null == notNull {
+			t.Errorf("%s %s: column NULL is %v but the gorm tag is %q", field.Name, field.Type, column.Null, gormTag(field))
+		}
+	}
+}
+
+func TestSQLDefault(t *testing.T) {
+	tests := []struct {
+		field Field
+		want  string
+		ok    bool
+	}{
+		{Field{Type: "string", Default: "it's"}, "'it''s'", true},
+		{Field{Type: "int", Default: "-3"}, "-3", true},
+		{Field{Type: "int", Default: "3; DROP TABLE users"}, "", false},
+		{Field{Type: "uint", Default: "-3"}, "", false},
+		{Field{Type: "*decimal.Decimal", Default: "9.99"}, "9.99", true},
+		{Field{Type: "bool", Default: "true"}, "1", true},
+		{Field{Type: "bool", Default: "yes"}, "", false},
+		{Field{Type: "time.Time", Default: "2024-01-01 00:00:00"}, "'2024-01-01 00:00:00'", true},
+	}
+	for _, test := range tests {
+		got, err := sqlDefault(test.field)
+		if (err == nil) != test.ok {
+			t.Errorf("sqlDefault(%s %q) error = %v", test.field.Type, test.field.Default, err)
+		} else if test.ok && got != test.want {
+			t.Errorf("sqlDefault(%s %q) = %s, want %s", test.field.Type, test.field.Default, got, test.want)
+		}
+	}
+}
+
+func TestCheckFieldOptionsDefault(t *testing.T) {
+	if err := loadConfigFieldTypes([]FieldType{{Name: "counter", GoType: "int64"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer loadConfigFieldTypes(nil)
+
+	field := Field{Name: "Count", Type: "int64", Kind: "counter", Default: "many"}
+	if problems := checkFieldOptions(field); problems["default"] == "" {
+		t.Errorf("accepted default %q for %s", field.Default, field.Type)
+	}
+}
@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// informationSchemaColumn is a row of information_schema.COLUMNS.
+type informationSchemaColumn struct {
+	Table      string
+	Name       string
+	ColumnType string
+	IsNullable string
+	Default    *string
+	Extra      string
+	Comment    string
+}
+
+// informationSchemaIndex is a row of information_schema.STATISTICS.
+type informationSchemaIndex struct {
+	Table     string
+	Name      string
+	Column    string
+	NonUnique int
+}
+
+// informationSchemaForeignKey is a row of information_schema.KEY_COLUMN_USAGE
+// for a foreign key.
+type informationSchemaForeignKey struct {
+	Table     string
+	Name      string
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+var integerDisplayWidthRe = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+
+// normalizeColumnType returns the MySQL column type as sqlDataType spells it.
+func normalizeColumnType(columnType string) string {
+	columnType = strings.ToLower(strings.TrimSpace(columnType))
+	if columnType == "tinyint(1)" {
+		return "boolean"
+	}
+	return integerDisplayWidthRe.ReplaceAllString(columnType, "$1")
+}
+
+// isQuotedDefaultType reports whether defaults of columnType are written as
+// string literals.
+func isQuotedDefaultType(columnType string) bool {
+	for _, prefix := range []string{"char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "date", "time", "year"} {
+		if strings.HasPrefix(columnType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// databaseSchema reads the tables of the database db is connected to from
+// information_schema.
+func databaseSchema(db *gorm.DB) (sqlSchema, error) {
+	var columns []informationSchemaColumn
+	err := db.Raw(`SELECT TABLE_NAME AS ` + "`table`" + `, COLUMN_NAME AS name, COLUMN_TYPE AS column_type,
+		IS_NULLABLE AS is_nullable, COLUMN_DEFAULT AS ` + "`default`" + `, EXTRA AS extra, COLUMN_COMMENT AS comment
+		FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, ORDINAL_POSITION`).Scan(&columns).Error
+	if err != nil {
+		return nil, err
+	}
+	var indexes []informationSchemaIndex
+	err = db.Raw(`SELECT TABLE_NAME AS ` + "`table`" + `, INDEX_NAME AS name, COLUMN_NAME AS ` + "`column`" + `, NON_UNIQUE AS non_unique
+		FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`).Scan(&indexes).Error
+	if err != nil {
+		return nil, err
+	}
+	var foreignKeys []informationSchemaForeignKey
+	err = db.Raw(`SELECT TABLE_NAME AS ` + "`table`" + `, CONSTRAINT_NAME AS name, COLUMN_NAME AS ` + "`column`" + `,
+		REFERENCED_TABLE_NAME AS ref_table, REFERENCED_COLUMN_NAME AS ref_column
+		FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME`).Scan(&foreignKeys).Error
+	if err != nil {
+		return nil, err
+	}
+	return buildDatabaseSchema(columns, indexes, foreignKeys), nil
+}
+
+// buildDatabaseSchema assembles the rows read from information_schema into
+// tables described the way schemaOf describes them.
+func buildDatabaseSchema(columns []informationSchemaColumn, indexes []informationSchemaIndex, foreignKeys []informationSchemaForeignKey) sqlSchema {
+	schema := sqlSchema{}
+	for _, row := range columns {
+		table := schema[row.Table]
+		if table == nil {
+			table = &sqlTable{Name: row.Table}
+			schema[row.Table] = table
+		}
+		columnType := normalizeColumnType(row.ColumnType)
+		column := sqlColumn{
+			Name:          row.Name,
+			Type:          columnType,
+			Null:          row.IsNullable == "YES",
+			Comment:       row.Comment,
+			AutoIncrement: strings.Contains(strings.ToLower(row.Extra), "auto_increment"),
+		}
+		if row.Default != nil && !(column.Null && strings.EqualFold(*row.Default, "NULL")) {
+			column.Default = *row.Default
+			if isQuotedDefaultType(columnType) && !strings.Contains(strings.ToLower(row.Extra), "default_generated") {
+				column.Default = sqlLiteral(*row.Default)
+			}
+		}
+		table.Columns = append(table.Columns, column)
+	}
+
+	for _, row := range foreignKeys {
+		if table := schema[row.Table]; table != nil {
+			table.ForeignKeys = append(table.ForeignKeys, sqlForeignKey{
+				Name: row.Name, Column: row.Column, RefTable: row.RefTable, RefColumn: row.RefColumn,
+			})
+		}
+	}
+
+	// Rows of one index are adjacent and ordered by position.
+	for i := 0; i < len(indexes); {
+		row := indexes[i]
+		index := sqlIndex{Name: row.Name}
+		for ; i < len(indexes) && indexes[i].Table == row.Table && indexes[i].Name == row.Name; i++ {
+			index.Columns = append(index.Columns, indexes[i].Column)
+		}
+		table := schema[row.Table]
+		switch {
+		case table == nil:
+		case row.Name == "PRIMARY":
+			table.PrimaryKey = index.Columns
+		case row.NonUnique == 0 && len(index.Columns) == 1:
+			if column := table.column(index.Columns[0]); column != nil {
+				column.Unique = true
+			}
+		case hasForeignKeyNamed(table, row.Name):
+			// MySQL adds an index to back each foreign key.
+		default:
+			table.Indexes = append(table.Indexes, index)
+		}
+	}
+	return schema
+}
+
+func hasForeignKeyNamed(table *sqlTable, name string) bool {
+	for _, key := range table.ForeignKeys {
+		if key.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// snapshotDir keeps a copy of every file as it was last generated, so hand
+// edits can be told apart from generated content.
+const snapshotDir = ".grails/generated"
+
+// EditedFilesError reports generated files that were changed by hand.
+type EditedFilesError struct {
+	Model string
+	Files []string
+}
+
+func (e *EditedFilesError) Error() string {
+	return fmt.Sprintf("%s has hand-edited or untracked files: %s", e.Model, strings.Join(e.Files, ", "))
+}
+
+func snapshotPath(modelName, path string) string {
+	return filepath.Join(snapshotDir, modelName, path)
+}
+
+// snapshotScaffold records files, keyed by path, as generated for modelName.
+func snapshotScaffold(modelName string, files map[string][]byte) error {
+	for path, content := range files {
+		target := snapshotPath(modelName, path)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSnapshot returns the content path was last generated with for
+// modelName, or nil when it was never recorded.
+func readSnapshot(modelName, path string) ([]byte, error) {
+	content, err := os.ReadFile(snapshotPath(modelName, path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
+
+// editedFiles lists the existing paths whose content differs from the
+// snapshot of modelName, including files that were never snapshotted.
+func editedFiles(modelName string, paths []string) ([]string, error) {
+	var edited []string
+	for _, path := range paths {
+		current, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		generated, err := os.ReadFile(snapshotPath(modelName, path))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err != nil || !bytes.Equal(current, generated) {
+			edited = append(edited, path)
+		}
+	}
+	return edited, nil
+}
+
+func removeSnapshot(modelName string) error {
+	return os.RemoveAll(filepath.Join(snapshotDir, modelName))
+}
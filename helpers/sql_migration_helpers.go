@@ -0,0 +1,190 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// migrationsDir holds the versioned SQL migrations, one up and one down
+// script per version.
+const migrationsDir = "migrations"
+
+// migrationVersionLayout formats the timestamp that versions a migration.
+const migrationVersionLayout = "20060102150405"
+
+var migrationFileRe = regexp.MustCompile(`^(\d{14})_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one versioned SQL migration in migrationsDir.
+type Migration struct {
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	Up      string `json:"up"`             // path of the up script
+	Down    string `json:"down,omitempty"` // path of the down script, empty if there is none
+}
+
+// ReadMigrations lists the migrations in migrationsDir, oldest first.
+func ReadMigrations() ([]Migration, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseMigrations(entriesNames(entries))
+}
+
+func entriesNames(entries []os.DirEntry) []string {
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// parseMigrations groups the up and down scripts among the file names of
+// migrationsDir by version.
+func parseMigrations(fileNames []string) ([]Migration, error) {
+	byVersion := map[string]*Migration{}
+	for _, fileName := range fileNames {
+		match := migrationFileRe.FindStringSubmatch(fileName)
+		if match == nil {
+			continue
+		}
+		version, name, direction := match[1], match[2], match[3]
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("migrations %s_%s and %s_%s share a version", version, migration.Name, version, name)
+		}
+		path := filepath.Join(migrationsDir, fileName)
+		if direction == "up" {
+			migration.Up = path
+		} else {
+			migration.Down = path
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %s_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// plannedMigrations lists the migrations once plan is applied.
+func plannedMigrations(plan *ScaffoldPlan) ([]Migration, error) {
+	entries, err := os.ReadDir(migrationsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var fileNames []string
+	for _, fileName := range entriesNames(entries) {
+		if change := plan.find(filepath.Join(migrationsDir, fileName)); change == nil || change.After != nil {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	for _, change := range plan.Changes {
+		if filepath.Dir(change.Path) == migrationsDir && change.Before == nil && change.After != nil {
+			fileNames = append(fileNames, filepath.Base(change.Path))
+		}
+	}
+	return parseMigrations(fileNames)
+}
+
+// nextMigrationVersion returns the current time as a version, moved past the
+// newest of migrations so versions stay unique and ordered.
+func nextMigrationVersion(migrations []Migration) string {
+	next := time.Now().UTC().Truncate(time.Second)
+	if len(migrations) > 0 {
+		newest, err := time.Parse(migrationVersionLayout, migrations[len(migrations)-1].Version)
+		if err == nil && !next.After(newest) {
+			next = newest.Add(time.Second)
+		}
+	}
+	return next.Format(migrationVersionLayout)
+}
+
+// migrationScript renders statements as the body of a migration file.
+func migrationScript(statements []string) []byte {
+	return []byte(strings.Join(statements, "\n\n") + "\n")
+}
+
+// planSchemaMigration plans the up and down scripts migrating the database
+// from the models.json the plan started from to the one it writes.
+func planSchemaMigration(plan *ScaffoldPlan, name string) error {
+	change := plan.find(jsonFilePath)
+	if change == nil {
+		return nil
+	}
+	migrations, err := plannedMigrations(plan)
+	if err != nil {
+		return err
+	}
+
+	before, err := parseModelsJSON(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := parseModelsJSON(change.After)
+	if err != nil {
+		return err
+	}
+
+	beforeSchema, afterSchema := schemaOf(before), schemaOf(after)
+	if len(migrations) == 0 && len(beforeSchema) > 0 {
+		if err := planBaselineMigration(plan, beforeSchema); err != nil {
+			return err
+		}
+		if migrations, err = plannedMigrations(plan); err != nil {
+			return err
+		}
+	}
+	return planMigrationFiles(plan, migrations, name, beforeSchema, afterSchema)
+}
+
+// baselineMigrationName names the migration creating the tables of the models
+// scaffolded before migrations were versioned.
+const baselineMigrationName = "baseline"
+
+// planBaselineMigration plans the first migration, creating the tables of
+// schema unless AutoMigrate already did. Its down script keeps them.
+func planBaselineMigration(plan *ScaffoldPlan, schema sqlSchema) error {
+	prefix := filepath.Join(migrationsDir, nextMigrationVersion(nil)+"_"+baselineMigrationName)
+	up := append([]string{"-- Tables of the models scaffolded before migrations were versioned."}, diffSchemas(sqlSchema{}, schema)...)
+	if err := plan.write(prefix+".up.sql", migrationScript(up)); err != nil {
+		return err
+	}
+	return plan.write(prefix+".down.sql", migrationScript([]string{"-- The baseline keeps the tables it found."}))
+}
+
+// planMigrationFiles plans the next migration after migrations, named name,
+// from the schema before to after, unless they are the same.
+func planMigrationFiles(plan *ScaffoldPlan, migrations []Migration, name string, before, after sqlSchema) error {
+	up := diffSchemas(before, after)
+	if len(up) == 0 {
+		return nil
+	}
+	down := diffSchemas(after, before)
+
+	prefix := filepath.Join(migrationsDir, nextMigrationVersion(migrations)+"_"+name)
+	if err := plan.write(prefix+".up.sql", migrationScript(up)); err != nil {
+		return err
+	}
+	return plan.write(prefix+".down.sql", migrationScript(down))
+}
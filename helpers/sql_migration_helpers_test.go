@@ -0,0 +1,152 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// chdirTemp runs the rest of the test inside an empty project directory.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// writeGoMod gives the test project the module path generated imports use.
+func writeGoMod(t *testing.T) {
+	t.Helper()
+	if err := os.WriteFile("go.mod", []byte("module example.com/app\n\ngo 1.19\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlanSchemaMigration(t *testing.T) {
+	const users = `"users": [{"name": "Email", "type": "string"}]`
+	const posts = `"posts": [{"name": "Title", "type": "string"}]`
+	const postsOfUser = `"Post": {"fields": [{"name": "Title", "type": "string"}], "associations": [{"kind": "belongs_to", "model": "user"}]}`
+
+	tests := []struct {
+		name       string
+		migrations bool
+		before     string
+		after      string
+		scripts    map[string][]string // statements each script must contain, by file suffix
+		absent     map[string][]string // text each script may not contain
+	}{
+		{
+			name:   "first migration creates existing models in a baseline",
+			before: "{" + users + "}",
+			after:  "{" + users + "," + posts + "}",
+			scripts: map[string][]string{
+				"baseline.up.sql": {"CREATE TABLE IF NOT EXISTS `users`"},
+				"change.up.sql":   {"CREATE TABLE IF NOT EXISTS `posts`"},
+				"change.down.sql": {"DROP TABLE IF EXISTS `posts`;"},
+			},
+			absent: map[string][]string{
+				"baseline.down.sql": {"DROP"},
+				"change.up.sql":     {"`users`"},
+				"change.down.sql":   {"`users`"},
+			},
+		},
+		{
+			name:   "first migration references a baseline table",
+			before: "{" + users + "}",
+			after:  "{" + users + "," + postsOfUser + "}",
+			scripts: map[string][]string{
+				"baseline.up.sql": {"CREATE TABLE IF NOT EXISTS `users`"},
+				"change.up.sql":   {"REFERENCES `users` (`id`)"},
+			},
+		},
+		{
+			name:   "first migration drops a removed existing model",
+			before: "{" + users + "," + posts + "}",
+			after:  "{" + posts + "}",
+			scripts: map[string][]string{
+				"baseline.up.sql": {"CREATE TABLE IF NOT EXISTS `users`", "CREATE TABLE IF NOT EXISTS `posts`"},
+				"change.up.sql":   {"DROP TABLE IF EXISTS `users`;"},
+			},
+		},
+		{
+			name:   "first migration of a new project",
+			before: "{}",
+			after:  "{" + posts + "}",
+			scripts: map[string][]string{
+				"change.up.sql": {"CREATE TABLE IF NOT EXISTS `posts`"},
+			},
+		},
+		{
+			name:       "later migrations diff models.json",
+			migrations: true,
+			before:     "{" + users + "}",
+			after:      "{" + posts + "}",
+			scripts: map[string][]string{
+				"change.up.sql":   {"CREATE TABLE IF NOT EXISTS `posts`", "DROP TABLE IF EXISTS `users`;"},
+				"change.down.sql": {"CREATE TABLE IF NOT EXISTS `users`", "DROP TABLE IF EXISTS `posts`;"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chdirTemp(t)
+			if test.migrations {
+				if err := os.MkdirAll(migrationsDir, os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(migrationsDir, "20240101000000_init.up.sql"), []byte("\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			plan := &ScaffoldPlan{Changes: []FileChange{{Path: jsonFilePath, Before: []byte(test.before), After: []byte(test.after)}}}
+			if err := planSchemaMigration(plan, "change"); err != nil {
+				t.Fatal(err)
+			}
+
+			scripts := map[string]string{}
+			var order []string
+			for _, change := range plan.Changes[1:] {
+				match := migrationFileRe.FindStringSubmatch(filepath.Base(change.Path))
+				if match == nil {
+					t.Fatalf("planned %s", change.Path)
+				}
+				suffix := match[2] + "." + match[3] + ".sql"
+				scripts[suffix] = string(change.After)
+				order = append(order, match[1]+" "+match[2])
+			}
+			wantFiles := map[string]bool{}
+			for suffix := range test.scripts {
+				name := strings.SplitN(suffix, ".", 2)[0]
+				wantFiles[name+".up.sql"], wantFiles[name+".down.sql"] = true, true
+			}
+			if len(scripts) != len(wantFiles) {
+				t.Fatalf("planned %v", order)
+			}
+			if _, ok := scripts["baseline.up.sql"]; ok && !sort.StringsAreSorted(order) {
+				t.Errorf("the baseline does not come first: %v", order)
+			}
+			for suffix, statements := range test.scripts {
+				for _, want := range statements {
+					if !strings.Contains(scripts[suffix], want) {
+						t.Errorf("%s lacks %q:\n%s", suffix, want, scripts[suffix])
+					}
+				}
+			}
+			for suffix, texts := range test.absent {
+				for _, text := range texts {
+					if strings.Contains(scripts[suffix], text) {
+						t.Errorf("%s mentions %s:\n%s", suffix, text, scripts[suffix])
+					}
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package helpers
 
-import "strings"
+import (
+	"strings"
+	"unicode"
+)
 
 const (
 	Reset     = "\033[0m"
@@ -30,3 +33,21 @@ func ToCamelCase(str string) string {
 	}
 	return strings.Join(parts, "")
 }
+
+// Converts a CamelCase string to snake_case.
+func ToSnakeCase(str string) string {
+	var out strings.Builder
+	runes := []rune(str)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])
+			if prevLower || nextLower {
+				out.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
@@ -0,0 +1,350 @@
+package helpers
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// defaultTemplates are the scaffold templates shipped with grails. They use
+// [[ ]] delimiters so the {{ }} of generated views pass through untouched.
+//
+//go:embed templates/scaffold/*.tmpl
+var defaultTemplates embed.FS
+
+// templateDir holds the default templates in defaultTemplates and, relative
+// to the project, the overrides that take precedence over them.
+const templateDir = "templates/scaffold"
+
+// templateField is a Field as scaffold templates see it.
+type templateField struct {
+	Name        string // main_title, the column name
+	Key         string // main_title by default, the json and form key
+	GoName      string // MainTitle, the struct field
+	Label       string // Main title
+	Type        string // the Go type
+	InputType   string // the HTML input type, or textarea
+	Step        string // the step of number inputs, empty for whole numbers
+	Parse       string // how the edit script reads the value, see FieldType
+	Format      string // the view function index and show display it with, if any
+	Pattern     string // the pattern attribute of the input, if any
+	Placeholder string // an example value from the field type, if any
+	Pointer     bool   // whether the Go type is a pointer, so may be nil
+	Tag         string // the struct tag, including backquotes
+	Required    bool   // whether forms require a value
+	MaxLength   int    // the maxlength of text inputs, 0 for none
+	Default     string // the initial value of insert forms, or of the textarea
+	Checked     bool   // whether insert forms check the checkbox initially
+	// EnumType is the string type the model declares for an enum field, such
+	// as PostStatus, with a constant for each of its Values.
+	EnumType string
+	Values   []templateEnumValue
+	// Accept is the accept attribute of the file input of an upload field,
+	// and Limits the storage.Limits its handlers check uploads with.
+	Accept string
+	Limits string
+}
+
+// templateEnumValue is a value of an enum field.
+type templateEnumValue struct {
+	Const string // PostStatusDraft
+	Value string // draft
+	Label string // Draft
+}
+
+// templateReference is a model the scaffold is associated with.
+type templateReference struct {
+	ScaffoldNames
+	// Key is the json and form key of the <Model>ID field of a belongs-to
+	// association, such as user_id, and the json key of the slice of a
+	// has-many or many-to-many one, such as tags.
+	Key     string
+	Tag     string // the struct tag of that field, including backquotes
+	Display string // the field of the other model its records are shown by
+}
+
+// scaffoldTemplateData is what every scaffold template is executed with.
+type scaffoldTemplateData struct {
+	ScaffoldNames
+	Fields []templateField
+	// References are the models the scaffold belongs to, HasMany the ones
+	// holding its ID and ManyToMany the ones joined to it through a join model.
+	References  []templateReference
+	HasMany     []templateReference
+	ManyToMany  []templateReference
+	ProjectName string
+	// AppVar and DBVar are the parameter names of SetupRoutes, for routes.go.tmpl.
+	AppVar string
+	DBVar  string
+	// Imports are the packages the field types come from, which the model
+	// imports besides gorm.
+	Imports []string
+}
+
+// Blobs reports whether fields hold the content of files, which handlers
+// read whole.
+func (d scaffoldTemplateData) Blobs() bool {
+	for _, field := range d.Fields {
+		if field.Parse == "file" {
+			return true
+		}
+	}
+	return false
+}
+
+// Uploads reports whether fields hold files kept by the storage, which the
+// handlers then import.
+func (d scaffoldTemplateData) Uploads() bool {
+	for _, field := range d.Fields {
+		if field.Parse == "upload" {
+			return true
+		}
+	}
+	return false
+}
+
+// Multipart reports whether the forms upload files, and so post multipart
+// data.
+func (d scaffoldTemplateData) Multipart() bool {
+	for _, field := range d.Fields {
+		if field.Parse == "file" || field.Parse == "upload" {
+			return true
+		}
+	}
+	return false
+}
+
+func newScaffoldTemplateData(names ScaffoldNames, fields []Field, associations []Association) (scaffoldTemplateData, error) {
+	modulePath, err := ModulePath()
+	if err != nil {
+		return scaffoldTemplateData{}, err
+	}
+	config, err := ReadProjectConfig()
+	if err != nil {
+		return scaffoldTemplateData{}, err
+	}
+	tagKey := tagCases[config.TagCase]
+
+	data := scaffoldTemplateData{
+		ScaffoldNames: names,
+		ProjectName:   modulePath,
+		Imports:       fieldImports(fields),
+	}
+	for _, association := range associations {
+		ref := templateReference{ScaffoldNames: NewScaffoldNames(association.Model)}
+		if ref.Display, err = displayField(ref.Model, association.Display); err != nil {
+			return scaffoldTemplateData{}, err
+		}
+		switch association.Kind {
+		case BelongsTo:
+			ref.Key = tagKey(ref.Table + "_id")
+			ref.Tag = fmt.Sprintf("`json:%q form:%q`", ref.Key, ref.Key)
+			data.References = append(data.References, ref)
+		case HasMany:
+			ref.Key = tagKey(ref.ViewDir)
+			ref.Tag = fmt.Sprintf("`json:%q`", ref.Key+",omitempty")
+			data.HasMany = append(data.HasMany, ref)
+		case ManyToMany:
+			ref.Key = tagKey(ref.ViewDir)
+			ref.Tag = fmt.Sprintf("`json:%q gorm:%q`", ref.Key+",omitempty", "many2many:"+joinNames(names, ref.ScaffoldNames).ViewDir)
+			data.ManyToMany = append(data.ManyToMany, ref)
+		}
+	}
+	for _, field := range fields {
+		spec := fieldTypeOf(field)
+		inputType := spec.Input
+		key := tagKey(field.Name)
+		viewField := templateField{
+			Name:      field.Name,
+			Key:       key,
+			GoName:    ToCamelCase(field.Name),
+			Label:     Humanize(field.Name),
+			Type:      field.Type,
+			InputType: inputType,
+			Parse:     spec.Parse,
+			Format:    spec.Format,
+			Pattern:   spec.Pattern,
+			Pointer:   strings.HasPrefix(field.Type, "*"),
+			Tag:       fieldTag(field, key),
+			// A required checkbox could only ever be submitted checked.
+			Required: !isNullable(field) && inputType != "checkbox",
+			Default:  field.Default,
+		}
+		if field.Default == "" {
+			viewField.Placeholder = spec.example(field.Name)
+		}
+		if spec.Parse == "upload" {
+			viewField.Accept = field.Accept
+			if viewField.Accept == "" {
+				viewField.Accept = spec.Accept
+			}
+			viewField.Accept = strings.Join(acceptTypes(viewField.Accept), ",")
+			viewField.Limits = uploadLimits(field, spec)
+			viewField.Placeholder = ""
+		}
+		if isEnum(field) {
+			// Nullable enums are pointers: nil is stored as NULL, where MySQL
+			// would reject an empty string.
+			viewField.EnumType = names.Model + viewField.GoName
+			viewField.Type = viewField.EnumType
+			if field.Nullable {
+				viewField.Type = "*" + viewField.EnumType
+			}
+			viewField.Pointer = field.Nullable
+			for _, value := range field.Values {
+				viewField.Values = append(viewField.Values, templateEnumValue{
+					Const: viewField.EnumType + ToCamelCase(value),
+					Value: value,
+					Label: Humanize(value),
+				})
+			}
+		}
+		if spec.Parse == "float" || spec.Parse == "decimal" {
+			// Decimals without a precision are stored with two places, like
+			// sqlDataType says; floats without one take any step.
+			places := field.Scale
+			if field.Precision == 0 && spec.Parse == "decimal" {
+				places = 2
+			}
+			switch {
+			case places > 0:
+				viewField.Step = "0." + strings.Repeat("0", places-1) + "1"
+				viewField.Format = fmt.Sprintf("formatDecimal %d", places)
+			case field.Precision == 0:
+				viewField.Step = "any"
+			}
+		}
+		switch inputType {
+		case "text":
+			viewField.MaxLength = field.Size
+		case "checkbox":
+			viewField.Checked, _ = strconv.ParseBool(field.Default)
+			viewField.Default = ""
+		}
+		data.Fields = append(data.Fields, viewField)
+	}
+	return data, nil
+}
+
+// newJoinTemplateData returns the data join.go.tmpl renders the join model of
+// names and other with: its References are the two joined models.
+func newJoinTemplateData(names, other ScaffoldNames) (scaffoldTemplateData, error) {
+	modulePath, err := ModulePath()
+	if err != nil {
+		return scaffoldTemplateData{}, err
+	}
+	config, err := ReadProjectConfig()
+	if err != nil {
+		return scaffoldTemplateData{}, err
+	}
+	tagKey := tagCases[config.TagCase]
+
+	data := scaffoldTemplateData{
+		ScaffoldNames: joinNames(names, other),
+		ProjectName:   modulePath,
+	}
+	for _, ref := range []ScaffoldNames{names, other} {
+		key := tagKey(ref.Table + "_id")
+		data.References = append(data.References, templateReference{
+			ScaffoldNames: ref,
+			Key:           key,
+			Tag:           fmt.Sprintf("`json:%q gorm:%q`", key, "primaryKey"),
+		})
+	}
+	return data, nil
+}
+
+// templateFuncs are available to scaffold templates, including overrides.
+var templateFuncs = template.FuncMap{
+	"camel":     ToCamelCase,
+	"snake":     ToSnakeCase,
+	"kebab":     ToKebabCase,
+	"plural":    Pluralize,
+	"singular":  Singularize,
+	"humanize":  Humanize,
+	"inputType": GetHTMLInputType,
+}
+
+// loadScaffoldTemplate returns the source of the template name, preferring
+// the project's override, and the path it was read from.
+func loadScaffoldTemplate(name string) ([]byte, string, error) {
+	override := filepath.Join(templateDir, name)
+	content, err := os.ReadFile(override)
+	if err == nil {
+		return content, override, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, override, err
+	}
+
+	builtin := path.Join(templateDir, name)
+	content, err = defaultTemplates.ReadFile(builtin)
+	return content, "built-in " + builtin, err
+}
+
+// renderScaffoldTemplate executes the template name with data.
+func renderScaffoldTemplate(name string, data scaffoldTemplateData) ([]byte, error) {
+	src, source, err := loadScaffoldTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(name).Delims("[[", "]]").Funcs(templateFuncs).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to execute %s: %w", source, err)
+	}
+	return out.Bytes(), nil
+}
+
+// renderGoTemplate executes the template name and gofmts the result. Output
+// that does not parse is returned as is, so validation can point at it.
+func renderGoTemplate(name string, data scaffoldTemplateData) ([]byte, error) {
+	content, err := renderScaffoldTemplate(name, data)
+	if err != nil {
+		return nil, err
+	}
+	if formatted, err := format.Source(content); err == nil {
+		return formatted, nil
+	}
+	return content, nil
+}
+
+// EjectTemplates copies the default templates into templates/scaffold and
+// returns the files written.
+func EjectTemplates(force bool) ([]string, error) {
+	entries, err := defaultTemplates.ReadDir(templateDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(templateDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	for _, entry := range entries {
+		target := filepath.Join(templateDir, entry.Name())
+		if _, err := os.Stat(target); err == nil && !force {
+			continue
+		}
+		content, err := defaultTemplates.ReadFile(path.Join(templateDir, entry.Name()))
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
@@ -0,0 +1,178 @@
+package helpers
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFieldRequired(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+
+	fields := []Field{
+		{Name: "title", Type: "string"},
+		{Name: "subtitle", Type: "string", Nullable: true},
+		{Name: "price", Type: "*decimal.Decimal", Kind: "decimal", Precision: 10, Scale: 2},
+		{Name: "published", Type: "bool"},
+	}
+	data, err := newScaffoldTemplateData(NewScaffoldNames("product"), fields, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"title": true, "subtitle": false, "price": false, "published": false}
+	for _, field := range data.Fields {
+		if field.Required != want[field.Name] {
+			t.Errorf("%s: Required = %v, want %v", field.Name, field.Required, want[field.Name])
+		}
+	}
+}
+
+func TestPlanModelAssociationViews(t *testing.T) {
+	plan := planPostWithAssociations(t)
+
+	checkContains(t, plan, "handlers/post_handlers.go",
+		`db.Preload("User").Find(&posts)`,
+		`db.Preload("User").Preload("Comments").Preload("Tags").First(&post, c.Params("id"))`,
+		`data["Users"] = users`,
+	)
+	checkContains(t, plan, "views/posts/insert.html",
+		`<select id="user_id" name="user_id" required>`,
+		`{{range .Users}}`,
+		`<option value="{{.ID}}">{{.Name}}</option>`,
+	)
+	checkContains(t, plan, "views/posts/edit.html",
+		`<select id="user_id" name="user_id" data-type="int" required>`,
+		`<option value="{{.ID}}"{{if eq .ID $.post.UserID}} selected{{end}}>{{.Name}}</option>`,
+	)
+	checkContains(t, plan, "views/posts/index.html",
+		`<td>{{if .UserID}}<a href="/users/{{.User.ID}}">{{.User.Name}}</a>{{end}}</td>`,
+	)
+	checkContains(t, plan, "views/posts/show.html",
+		`<a href="/users/{{.ID}}">{{.Name}}</a>`,
+		`<a href="/comments/{{$record.ID}}">{{$record.Body}}</a>`,
+		`<a href="/tags/{{$record.ID}}">{{$record.Label}}</a>`,
+	)
+	if err := plan.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPlanModelAssociationDisplay(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	writeModels(t, map[string]string{
+		"user.go": "package models\n\ntype User struct {\n\tName  string\n\tEmail string\n}\n",
+	})
+	plan, err := PlanModel("post", nil, []Association{{Kind: BelongsTo, Model: "user", Display: "email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContains(t, plan, "views/posts/insert.html", `<option value="{{.ID}}">{{.Email}}</option>`)
+	checkContains(t, plan, "views/posts/index.html", `{{.User.Email}}`)
+
+	if _, err := PlanModel("post", nil, []Association{{Kind: BelongsTo, Model: "user", Display: "phone"}}); err == nil {
+		t.Error("planned a display column the model does not have")
+	}
+}
+
+func TestPlanModelTypedViews(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	plan, err := PlanModel("product", []Field{
+		{Name: "price", Type: "decimal", Precision: 10, Scale: 2},
+		{Name: "weight", Type: "float64"},
+		{Name: "active", Type: "bool"},
+		{Name: "released", Type: "time.Time"},
+		{Name: "birthday", Type: "date"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkContains(t, plan, "views/products/insert.html",
+		`<input type="number" id="price" name="price" step="0.01">`,
+		`<input type="number" id="weight" name="weight" step="any" required>`,
+		`<input type="checkbox" id="active" name="active">`,
+		`<input type="datetime-local" id="released" name="released" required>`,
+		`<input type="date" id="birthday" name="birthday" required>`,
+	)
+	checkContains(t, plan, "views/products/edit.html",
+		`data-type="decimal" value="{{with .product.Price}}{{.}}{{end}}" step="0.01">`,
+		`data-type="float" value="{{.product.Weight}}" step="any" required>`,
+		`data-type="bool"{{if .product.Active}} checked{{end}}>`,
+		`data-type="datetime" value="{{.product.Released.Format "2006-01-02T15:04"}}" required>`,
+		`data-type="datetime" value="{{.product.Birthday.Format "2006-01-02"}}" required>`,
+		`return input.value === '' ? null : parseFloat(input.value);`,
+	)
+
+	show, err := plan.current("views/products/show.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	view, err := template.New("show").Funcs(ViewFuncs).Parse(string(show))
+	if err != nil {
+		t.Fatal(err)
+	}
+	price := 1234.5
+	product := struct {
+		ID                 uint
+		Price              *float64
+		Weight             float64
+		Active             bool
+		Released, Birthday time.Time
+	}{1, &price, 2.25, true, time.Date(2024, 5, 1, 9, 15, 0, 0, time.UTC), time.Date(1990, 2, 3, 0, 0, 0, 0, time.UTC)}
+	var out strings.Builder
+	if err := view.Execute(&out, map[string]interface{}{"product": product}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<td>1,234.50</td>", "<td>2.25</td>", "<td>Yes</td>", "<td>2024-05-01 09:15</td>", "<td>1990-02-03</td>",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("show view lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPlanModelEnum(t *testing.T) {
+	chdirTemp(t)
+	writeGoMod(t)
+	plan, err := PlanModel("article", []Field{
+		{Name: "status", Type: "enum", Values: []string{"draft", "in_review", "published"}, Default: "draft"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkContains(t, plan, "models/article.go",
+		"Status ArticleStatus `json:\"status\" form:\"status\" gorm:\"type:enum('draft','in_review','published');not null;default:draft\"`",
+		"type ArticleStatus string",
+		`ArticleStatusInReview  ArticleStatus = "in_review"`,
+		"var ArticleStatusValues = []ArticleStatus{ArticleStatusDraft, ArticleStatusInReview, ArticleStatusPublished}",
+		"func (v ArticleStatus) Valid() bool {",
+	)
+	checkContains(t, plan, "handlers/article_handlers.go", "if !article.Status.Valid() {")
+	checkContains(t, plan, "views/articles/insert.html",
+		`<select id="status" name="status" required>`,
+		`<option value="in_review">In review</option>`,
+	)
+	checkContains(t, plan, "views/articles/edit.html",
+		`<option value="in_review"{{if eq .article.Status "in_review"}} selected{{end}}>In review</option>`,
+	)
+	checkContains(t, plan, "views/articles/show.html", "{{formatEnum .article.Status}}")
+	for _, change := range plan.Changes {
+		if strings.HasSuffix(change.Path, "_create_articles.up.sql") && !strings.Contains(string(change.After), "`status` enum('draft','in_review','published') NOT NULL DEFAULT 'draft'") {
+			t.Errorf("%s lacks the enum column:\n%s", change.Path, change.After)
+		}
+	}
+	if err := plan.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	field := SQLField("status", "enum('draft','in_review','published')")
+	if field.Kind != "enum" || strings.Join(field.Values, ",") != "draft,in_review,published" {
+		t.Errorf("imported enum column is %+v", field)
+	}
+}
@@ -0,0 +1,314 @@
+package helpers
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists what is wrong with each input of a scaffold request,
+// keyed such as "tableName" or "fields.0.name".
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, len(keys))
+	for i, key := range keys {
+		messages[i] = fmt.Sprintf("%s: %s", key, e.Fields[key])
+	}
+	return "invalid scaffold: " + strings.Join(messages, "; ")
+}
+
+var identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// gormModelColumns are provided by the gorm.Model embedded in every scaffold.
+var gormModelColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "deleted_at": true,
+}
+
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+}
+
+// mysqlReservedWords are the reserved keywords of MySQL 8.0, which can not be
+// used as unquoted table or column names.
+var mysqlReservedWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`
+		accessible add all alter analyze and as asc asensitive before between bigint
+		binary blob both by call cascade case change char character check collate
+		column condition constraint continue convert create cross cube cume_dist
+		current_date current_time current_timestamp current_user cursor database
+		databases day_hour day_microsecond day_minute day_second dec decimal declare
+		default delayed delete dense_rank desc describe deterministic distinct
+		distinctrow div double drop dual each else elseif empty enclosed escaped
+		except exists exit explain false fetch first_value float float4 float8 for
+		force foreign from fulltext function generated get grant group grouping
+		groups having high_priority hour_microsecond hour_minute hour_second if
+		ignore in index infile inner inout insensitive insert int int1 int2 int3
+		int4 int8 integer intersect interval into io_after_gtids io_before_gtids is
+		iterate join json_table key keys kill lag last_value lateral lead leading
+		leave left like limit linear lines load localtime localtimestamp lock long
+		longblob longtext loop low_priority master_bind master_ssl_verify_server_cert
+		match maxvalue mediumblob mediumint mediumtext middleint minute_microsecond
+		minute_second mod modifies natural not no_write_to_binlog nth_value ntile
+		null numeric of on optimize optimizer_costs option optionally or order out
+		outer outfile over partition percent_rank precision primary procedure purge
+		range rank read reads read_write real recursive references regexp release
+		rename repeat replace require resignal restrict return revoke right rlike
+		row rows row_number schema schemas second_microsecond select sensitive
+		separator set show signal smallint spatial specific sql sqlexception
+		sqlstate sqlwarning sql_big_result sql_calc_found_rows sql_small_result ssl
+		starting stored straight_join system table terminated then tinyblob tinyint
+		tinytext to trailing trigger true undo union unique unlock unsigned update
+		usage use using utc_date utc_time utc_timestamp values varbinary varchar
+		varcharacter varying virtual when where while window with write xor
+		year_month zerofill`) {
+		mysqlReservedWords[word] = true
+	}
+}
+
+// normalizeName turns user input such as "Blog Post" or "BlogPost" into the
+// snake_case form used for tables and columns.
+func normalizeName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '\t'
+	})
+	return strings.ToLower(ToSnakeCase(strings.Join(words, "_")))
+}
+
+// checkName returns what is wrong with a normalized table or column name.
+func checkName(name string) string {
+	switch {
+	case name == "":
+		return "is required"
+	case !identifierPattern.MatchString(name):
+		return "must start with a letter and contain only letters, digits and underscores"
+	case goKeywords[name]:
+		return fmt.Sprintf("%q is a Go keyword", name)
+	case mysqlReservedWords[name]:
+		return fmt.Sprintf("%q is a reserved word in MySQL", name)
+	}
+	return ""
+}
+
+// checkGoType returns what is wrong with a field type.
+func checkGoType(typ string) string {
+	if typ == "" {
+		return "is required"
+	}
+	expr, err := parser.ParseExpr(typ)
+	if err != nil || !isTypeExpr(expr) {
+		return fmt.Sprintf("%q is not a Go type", typ)
+	}
+	return ""
+}
+
+// isTypeExpr reports whether expr names a type, such as int, time.Time,
+// *decimal.Decimal, []byte or map[string]int.
+func isTypeExpr(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := expr.X.(*ast.Ident)
+		return ok
+	case *ast.StarExpr:
+		return isTypeExpr(expr.X)
+	case *ast.ArrayType:
+		if expr.Len != nil {
+			if length, ok := expr.Len.(*ast.BasicLit); !ok || length.Kind != token.INT {
+				return false
+			}
+		}
+		return isTypeExpr(expr.Elt)
+	case *ast.MapType:
+		return isTypeExpr(expr.Key) && isTypeExpr(expr.Value)
+	}
+	return false
+}
+
+// existingModels returns the structs declared in the models package and the
+// model names listed in models.json.
+func existingModels() (map[string]bool, error) {
+	existing := map[string]bool{}
+
+	models, err := ReadModelsFromJSON()
+	if err != nil {
+		return nil, err
+	}
+	for name := range models {
+		existing[ToCamelCase(name)] = true
+	}
+
+	paths, err := filepath.Glob(filepath.Join("models", "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					if _, ok := typeSpec.Type.(*ast.StructType); ok {
+						existing[typeSpec.Name.Name] = true
+					}
+				}
+			}
+		}
+	}
+	return existing, nil
+}
+
+// ValidateScaffold normalizes and checks the names of a scaffold request,
+// returning a *ValidationError listing every problem.
+func ValidateScaffold(tableName string, fields []Field, associations []Association) (string, []Field, []Association, error) {
+	problems := map[string]string{}
+
+	existing, err := existingModels()
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	tableName = normalizeName(tableName)
+	if problem := checkName(tableName); problem != "" {
+		problems["tableName"] = "Table name " + problem
+	} else if names := NewScaffoldNames(tableName); existing[names.Model] {
+		problems["tableName"] = fmt.Sprintf("A model named %s already exists", names.Model)
+	} else if names.clashesWithHandlers() {
+		problems["tableName"] = fmt.Sprintf("Table name %q clashes with a name used by the generated handlers", tableName)
+	}
+
+	var normalizedAssociations []Association
+	associated := map[string]int{}
+	for i, association := range associations {
+		key := fmt.Sprintf("associations.%d", i)
+		// models.json may list a model by its plural, such as "users".
+		ref := normalizeName(association.Model)
+		if ref == "" {
+			continue
+		}
+		if singular := Singularize(ref); !existing[ToCamelCase(ref)] || existing[ToCamelCase(singular)] {
+			ref = singular
+		}
+		association.Model = ref
+
+		if !associationKinds[association.Kind] {
+			problems[key+".kind"] = fmt.Sprintf("Association kind %q is not one of %s, %s or %s", association.Kind, BelongsTo, HasMany, ManyToMany)
+		}
+		if ref == tableName {
+			problems[key+".model"] = "A model cannot be associated with itself"
+		} else if !existing[ToCamelCase(ref)] {
+			problems[key+".model"] = fmt.Sprintf("There is no %s model to associate", ToCamelCase(ref))
+		} else if first, ok := associated[ref]; ok {
+			problems[key+".model"] = fmt.Sprintf("%s is already associated by association %d", ToCamelCase(ref), first+1)
+		} else {
+			associated[ref] = i
+			association.Display = normalizeName(association.Display)
+			if association.Display != "" {
+				if _, err := displayField(ToCamelCase(ref), association.Display); err != nil {
+					problems[key+".display"] = fmt.Sprintf("%s has no %s column to display", ToCamelCase(ref), association.Display)
+				}
+			}
+		}
+		normalizedAssociations = append(normalizedAssociations, association)
+	}
+
+	normalized := checkFields(fields, normalizedAssociations, problems)
+
+	if len(problems) > 0 {
+		return "", nil, nil, &ValidationError{Fields: problems}
+	}
+	return tableName, normalized, normalizedAssociations, nil
+}
+
+// checkFields normalizes fields and adds what is wrong with them to problems,
+// keyed by "fields.<i>.<option>".
+func checkFields(fields []Field, associations []Association, problems map[string]string) []Field {
+	seen := map[string]int{}
+	normalized := make([]Field, len(fields))
+	for i, field := range fields {
+		key := fmt.Sprintf("fields.%d", i)
+		field.Name = normalizeName(field.Name)
+		field.Type = strings.TrimSpace(field.Type)
+		// The type is either the Go type of the field type its Kind names, as
+		// in models.json, or the name of a field type, as the /dev form sends.
+		if kind, ok := LookupFieldType(field.Kind); ok && kind.GoType == field.Type {
+			field.Kind = kind.Name
+		} else if fieldType, ok := LookupFieldType(field.Type); ok {
+			field.Type, field.Kind = fieldType.GoType, fieldType.Name
+		} else {
+			field.Kind = fieldTypeOf(Field{Type: field.Type}).Name
+		}
+
+		if problem := checkName(field.Name); problem != "" {
+			problems[key+".name"] = "Field name " + problem
+		} else if gormModelColumns[field.Name] {
+			problems[key+".name"] = fmt.Sprintf("%s is already provided by gorm.Model", field.Name)
+		} else if first, ok := seen[field.Name]; ok {
+			problems[key+".name"] = fmt.Sprintf("%s is already used by field %d", field.Name, first+1)
+		} else {
+			seen[field.Name] = i
+		}
+		for _, association := range associations {
+			ref := association.Model
+			clashes := field.Name == Pluralize(ref)
+			if association.Kind == BelongsTo {
+				clashes = field.Name == ref || field.Name == ref+"_id"
+			}
+			if clashes {
+				problems[key+".name"] = fmt.Sprintf("%s clashes with the %s association", field.Name, ToCamelCase(ref))
+			}
+		}
+
+		if problem := checkGoType(field.Type); problem != "" {
+			problems[key+".type"] = "Field type " + problem
+		}
+		for i, value := range field.Values {
+			field.Values[i] = strings.TrimSpace(value)
+		}
+		field.Accept = strings.TrimSpace(field.Accept)
+		field.Default = strings.TrimSpace(field.Default)
+		field.Comment = strings.TrimSpace(field.Comment)
+		for option, problem := range checkFieldOptions(field) {
+			problems[key+"."+option] = problem
+		}
+		normalized[i] = field
+	}
+	return normalized
+}
+
+// ValidateFields normalizes and checks the fields of an existing scaffold with
+// associations, as ValidateScaffold does.
+func ValidateFields(fields []Field, associations []Association) ([]Field, error) {
+	problems := map[string]string{}
+	normalized := checkFields(fields, associations, problems)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Fields: problems}
+	}
+	return normalized, nil
+}
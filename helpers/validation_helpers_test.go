@@ -0,0 +1,72 @@
+package helpers
+
+import "testing"
+
+func TestCheckGoType(t *testing.T) {
+	tests := map[string]bool{
+		"int":                true,
+		"time.Time":          true,
+		"*decimal.Decimal":   true,
+		"datatypes.JSON":     true,
+		"[]byte":             true,
+		"[16]byte":           true,
+		"map[string]int":     true,
+		"":                   false,
+		"os.Exit(1)":         false,
+		"a+b":                false,
+		"func()":             false,
+		"struct{}":           false,
+		"[n]byte":            false,
+		"a.b.C":              false,
+		"\"string\"":         false,
+		"chan int":           false,
+		"interface{}":        false,
+		"[]os.Getenv(\"x\")": false,
+	}
+	for typ, ok := range tests {
+		if problem := checkGoType(typ); (problem == "") != ok {
+			t.Errorf("checkGoType(%q) = %q, want ok %v", typ, problem, ok)
+		}
+	}
+}
+
+func TestCheckFieldOptionsSQLType(t *testing.T) {
+	tests := map[string]bool{
+		"int unsigned":          true,
+		"char(8)":               true,
+		"decimal(8,3)":          true,
+		"mediumtext":            true,
+		"int; DROP TABLE users": false,
+		"varchar(10) NOT NULL":  false,
+		"int unsigned;not null": false,
+	}
+	for sqlType, ok := range tests {
+		problems := checkFieldOptions(Field{Name: "n", Type: "uint", SQLType: sqlType})
+		if _, bad := problems["sqlType"]; bad == ok {
+			t.Errorf("checkFieldOptions(%q) = %v, want ok %v", sqlType, problems, ok)
+		}
+	}
+}
+
+func TestCheckFieldOptionsEnum(t *testing.T) {
+	tests := []struct {
+		field   Field
+		problem string // the option with a problem, empty for none
+	}{
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"draft", "in_review"}, Default: "draft"}, ""},
+		{Field{Name: "status", Type: "string", Kind: "enum"}, "values"},
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"Draft"}}, "values"},
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"on hold"}}, "values"},
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"draft", "draft"}}, "values"},
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"in_review", "in__review"}}, "values"},
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"draft"}, Default: "archived"}, "default"},
+		{Field{Name: "status", Type: "string", Kind: "enum", Values: []string{"draft"}, Size: 20}, "size"},
+		{Field{Name: "status", Type: "string", Values: []string{"draft"}}, "values"},
+	}
+	for _, test := range tests {
+		problems := checkFieldOptions(test.field)
+		if _, ok := problems[test.problem]; test.problem != "" && !ok || test.problem == "" && len(problems) > 0 {
+			t.Errorf("checkFieldOptions(%+v) = %v, want a problem with %q", test.field, problems, test.problem)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MashukeAlam/grails-template/storage"
+)
+
+// ViewFuncs format field values for display in generated index and show
+// views. The app registers them on its html engine.
+var ViewFuncs = map[string]interface{}{
+	"formatTime":    FormatTime,
+	"formatDecimal": FormatDecimal,
+	"formatBool":    FormatBool,
+	"formatBytes":   FormatBytes,
+	"formatEnum":    FormatEnum,
+	"formatFile":    FormatFile,
+	"formatImage":   FormatImage,
+}
+
+// indirect dereferences value, reporting false for nil and nil pointers.
+func indirect(value interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// FormatTime formats a time.Time or *time.Time as 2006-01-02 15:04, dropping
+// the time of day at midnight. Zero and nil times are empty.
+func FormatTime(value interface{}) string {
+	value, ok := indirect(value)
+	t, isTime := value.(time.Time)
+	if !ok || !isTime || t.IsZero() {
+		return ""
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// FormatDecimal formats a float or decimal with places digits after the
+// point and thousands separated by commas, as amounts of money are shown.
+func FormatDecimal(places int, value interface{}) string {
+	value, ok := indirect(value)
+	if !ok {
+		return ""
+	}
+	var s string
+	switch v := value.(type) {
+	case float32:
+		s = strconv.FormatFloat(float64(v), 'f', places, 32)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', places, 64)
+	case interface{ StringFixed(int32) string }: // shopspring/decimal
+		s = v.StringFixed(int32(places))
+	default:
+		return fmt.Sprint(value)
+	}
+
+	sign, digits, fraction := "", s, ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if dot := strings.IndexByte(digits, '.'); dot >= 0 {
+		digits, fraction = digits[:dot], digits[dot:]
+	}
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return sign + digits + fraction
+}
+
+// FormatBool shows a bool as Yes or No, and a nil *bool as nothing.
+func FormatBool(value interface{}) string {
+	value, ok := indirect(value)
+	if !ok {
+		return ""
+	}
+	if b, _ := value.(bool); b {
+		return "Yes"
+	}
+	return "No"
+}
+
+// FormatBytes shows the size of binary data, as in 1.5 KB.
+func FormatBytes(value []byte) string {
+	return storage.FormatSize(int64(len(value)))
+}
+
+// FormatEnum shows the value of an enum as a label, as in In review for
+// in_review, and a nil one as nothing.
+func FormatEnum(value interface{}) string {
+	value, ok := indirect(value)
+	if !ok {
+		return ""
+	}
+	return Humanize(fmt.Sprint(value))
+}
+
+// uploadURL returns url escaped for an attribute, or false for URLs that are
+// not of uploads, such as javascript: ones.
+func uploadURL(url string) (string, bool) {
+	if !strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return "", false
+	}
+	return html.EscapeString(url), true
+}
+
+// FormatFile links to the upload served at url by its file name.
+func FormatFile(url string) template.HTML {
+	href, ok := uploadURL(url)
+	if !ok {
+		return template.HTML(html.EscapeString(url))
+	}
+	return template.HTML(fmt.Sprintf(`<a href="%s">%s</a>`, href, html.EscapeString(path.Base(url))))
+}
+
+// FormatImage shows the thumbnail of the image served at url, linking to the
+// image.
+func FormatImage(url string) template.HTML {
+	href, ok := uploadURL(url)
+	if !ok {
+		return template.HTML(html.EscapeString(url))
+	}
+	thumbnail := html.EscapeString(storage.ThumbnailURL(url))
+	return template.HTML(fmt.Sprintf(`<a href="%s"><img src="%s" alt=""></a>`, href, thumbnail))
+}
@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedDecimal stands in for decimal.Decimal, which FormatDecimal only knows
+// by its StringFixed method.
+type fixedDecimal string
+
+func (d fixedDecimal) StringFixed(places int32) string {
+	return string(d)
+}
+
+func TestFormatTime(t *testing.T) {
+	noon := time.Date(2024, 5, 1, 12, 30, 45, 0, time.UTC)
+	midnight := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var nilTime *time.Time
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{noon, "2024-05-01 12:30"},
+		{&noon, "2024-05-01 12:30"},
+		{midnight, "2024-05-01"},
+		{time.Time{}, ""},
+		{nilTime, ""},
+		{nil, ""},
+		{"2024-05-01", ""},
+	}
+	for _, test := range tests {
+		if got := FormatTime(test.value); got != test.want {
+			t.Errorf("FormatTime(%#v) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	price := 1234567.891
+	var nilPrice *float64
+	tests := []struct {
+		places int
+		value  interface{}
+		want   string
+	}{
+		{2, price, "1,234,567.89"},
+		{2, &price, "1,234,567.89"},
+		{0, 999.5, "1,000"},
+		{2, -1234.5, "-1,234.50"},
+		{1, float32(12.25), "12.2"},
+		{2, 12.0, "12.00"},
+		{3, fixedDecimal("-9876.500"), "-9,876.500"},
+		{2, nilPrice, ""},
+		{2, 42, "42"},
+	}
+	for _, test := range tests {
+		if got := FormatDecimal(test.places, test.value); got != test.want {
+			t.Errorf("FormatDecimal(%d, %#v) = %q, want %q", test.places, test.value, got, test.want)
+		}
+	}
+}
+
+func TestFormatBool(t *testing.T) {
+	yes := true
+	var unset *bool
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{true, "Yes"},
+		{false, "No"},
+		{&yes, "Yes"},
+		{unset, ""},
+	}
+	for _, test := range tests {
+		if got := FormatBool(test.value); got != test.want {
+			t.Errorf("FormatBool(%#v) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size int
+		want string
+	}{
+		{0, "0 bytes"},
+		{1536, "1.5 KB"},
+		{3 << 20, "3.0 MB"},
+	}
+	for _, test := range tests {
+		if got := FormatBytes(make([]byte, test.size)); got != test.want {
+			t.Errorf("FormatBytes of %d bytes = %q, want %q", test.size, got, test.want)
+		}
+	}
+}
+
+// status stands in for the string types generated for enums.
+type status string
+
+func TestFormatEnum(t *testing.T) {
+	review := status("in_review")
+	var unset *status
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{status("draft"), "Draft"},
+		{&review, "In review"},
+		{unset, ""},
+	}
+	for _, test := range tests {
+		if got := FormatEnum(test.value); got != test.want {
+			t.Errorf("FormatEnum(%#v) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
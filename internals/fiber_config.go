@@ -1,12 +1,53 @@
 package internals
 
 import (
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/MashukeAlam/grails-template/storage"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
+// formTimeLayouts are the formats date and time inputs submit, tried in order.
+var formTimeLayouts = []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02", time.RFC3339}
+
+// parseFormTime converts a submitted date or time into a time.Time in local
+// time. An empty value is the zero time.
+func parseFormTime(value string) reflect.Value {
+	if value == "" {
+		return reflect.ValueOf(time.Time{})
+	}
+	for _, layout := range formTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return reflect.ValueOf(t)
+		}
+	}
+	return reflect.Value{}
+}
+
+// uploadHeaders keeps browsers from sniffing uploads and has them download
+// anything that is not an image rather than display it.
+func uploadHeaders(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	if !strings.HasPrefix(string(c.Response().Header.ContentType()), "image/") {
+		c.Set(fiber.HeaderContentDisposition, "attachment")
+	}
+	return nil
+}
+
 func FiberAppStart(app *fiber.App) *fiber.App {
+	// Form decoding, with the defaults of fiber plus the time inputs of
+	// generated forms
+	fiber.SetParserDecoder(fiber.ParserConfig{
+		IgnoreUnknownKeys: true,
+		ZeroEmpty:         true,
+		ParserType:        []fiber.ParserType{{Customtype: time.Time{}, Converter: parseFormTime}},
+	})
+
 	// Middleware
 	app.Use(recover.New())
 	app.Use(logger.New())
@@ -15,5 +56,10 @@ func FiberAppStart(app *fiber.App) *fiber.App {
 	app.Static("/js", "./static/public/js")
 	app.Static("/img", "./static/public/img")
 	app.Static("/css", "./static/public/css")
+
+	// Files uploaded through generated forms, kept in UPLOAD_DIR
+	uploads := storage.NewLocal(os.Getenv("UPLOAD_DIR"), storage.DefaultURLPrefix)
+	storage.Uploads = uploads
+	app.Static(uploads.URLPrefix, uploads.Dir, fiber.Static{ModifyResponse: uploadHeaders})
 	return app
 }
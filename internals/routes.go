@@ -18,6 +18,16 @@ func SetupRoutes(app *fiber.App, dbGorm *gorm.DB) {
 	// Dev routes
 	Dev := app.Group("/dev")
 	Dev.Get("/", handlers.GetDevView())
-	Dev.Get("/migrate", handlers.GetMigration(dbGorm))
+	Dev.Get("/migrations", handlers.GetMigrations(dbGorm))
+	Dev.Post("/migrations/:action", handlers.RunMigrations(dbGorm))
+	Dev.Get("/schema", handlers.GetSchemaReport(dbGorm))
+	Dev.Post("/schema/migration", handlers.ReconcileSchema(dbGorm))
+	Dev.Get("/tables", handlers.GetImportableTables(dbGorm))
+	Dev.Post("/import", handlers.ImportTables(dbGorm))
+	Dev.Post("/preview", handlers.PreviewScaffold())
 	Dev.Post("/", handlers.ProcessIncomingScaffoldData(dbGorm))
+	Dev.Post("/destroy", handlers.DestroyScaffold())
+	Dev.Get("/models/:name", handlers.GetModelSchema())
+	Dev.Post("/update/preview", handlers.PreviewUpdate())
+	Dev.Post("/update", handlers.UpdateScaffold())
 }
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Storage keeps the files uploaded through generated forms. Records hold the
+// URL Save returns, which views link to.
+type Storage interface {
+	// Save stores content under name, a slash-separated relative path, and
+	// returns the URL it is served at.
+	Save(name string, content []byte) (string, error)
+	// Delete removes the file served at url. URLs of files it does not
+	// store, and files already gone, are ignored.
+	Delete(url string) error
+}
+
+// Uploads is the storage generated handlers save uploads to.
+var Uploads Storage = NewLocal("", DefaultURLPrefix)
+
+// DefaultDir and DefaultURLPrefix are where Local storages keep and serve
+// files unless told otherwise.
+const (
+	DefaultDir       = "uploads"
+	DefaultURLPrefix = "/uploads"
+)
+
+// Local stores files in a directory the app serves under URLPrefix.
+type Local struct {
+	Dir       string
+	URLPrefix string
+}
+
+// NewLocal returns the storage of dir, DefaultDir when empty, served under
+// urlPrefix.
+func NewLocal(dir, urlPrefix string) *Local {
+	if dir == "" {
+		dir = DefaultDir
+	}
+	return &Local{Dir: dir, URLPrefix: strings.TrimSuffix(urlPrefix, "/")}
+}
+
+// Save writes content to name under the directory.
+func (l *Local) Save(name string, content []byte) (string, error) {
+	file, err := l.path(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(file, content, 0o644); err != nil {
+		return "", err
+	}
+	return l.URLPrefix + "/" + name, nil
+}
+
+// Delete removes the file url serves from the directory.
+func (l *Local) Delete(url string) error {
+	name := strings.TrimPrefix(url, l.URLPrefix+"/")
+	if name == url {
+		return nil
+	}
+	file, err := l.path(name)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file name is stored in, refusing names leaving the
+// directory.
+func (l *Local) path(name string) (string, error) {
+	clean := path.Clean("/" + name)[1:]
+	if clean == "" || clean != name {
+		return "", errors.New("invalid file name " + name)
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(clean)), nil
+}
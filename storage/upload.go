@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Limits restrict what an upload field accepts.
+type Limits struct {
+	MaxSize int64 // the largest upload in bytes
+	// Accept lists the MIME types uploads may have, such as image/png or
+	// image/*, any when empty. Types are sniffed from the content, not taken
+	// from the client.
+	Accept []string
+	// Thumbnail stores a thumbnail of each upload next to it, which requires
+	// a JPEG, PNG or GIF image.
+	Thumbnail bool
+}
+
+// ErrNotUpload is returned by SaveDataURL for values that are no data URL,
+// such as the URL of another file.
+var ErrNotUpload = errors.New("not an uploaded file")
+
+// extensions are the file extensions uploads are stored with, by sniffed
+// type. Anything else is stored as .bin, so it is never served as a page.
+var extensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/bmp":       ".bmp",
+	"application/pdf": ".pdf",
+	"application/zip": ".zip",
+	"text/plain":      ".txt",
+	"text/csv":        ".csv",
+}
+
+// SaveFile stores a file posted in a multipart form to s and returns its URL.
+func SaveFile(s Storage, header *multipart.FileHeader, limits Limits) (string, error) {
+	if limits.MaxSize > 0 && header.Size > limits.MaxSize {
+		return "", tooLarge(limits.MaxSize)
+	}
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	return save(s, content, limits)
+}
+
+// SaveDataURL stores a file sent as a base64 data URL, as the edit forms send
+// them in JSON, to s and returns its URL.
+func SaveDataURL(s Storage, dataURL string, limits Limits) (string, error) {
+	header, data, ok := strings.Cut(dataURL, ",")
+	if !ok || !strings.HasPrefix(header, "data:") || !strings.HasSuffix(header, ";base64") {
+		return "", ErrNotUpload
+	}
+	// Refused before decoding when even the shortest content data could
+	// decode to is too large.
+	if limits.MaxSize > 0 && int64(base64.StdEncoding.DecodedLen(len(data))) > limits.MaxSize+2 {
+		return "", tooLarge(limits.MaxSize)
+	}
+	content, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", ErrNotUpload
+	}
+	return save(s, content, limits)
+}
+
+// save checks content against limits and stores it under a new random name
+// in a directory per month, with its thumbnail if asked to.
+func save(s Storage, content []byte, limits Limits) (string, error) {
+	if limits.MaxSize > 0 && int64(len(content)) > limits.MaxSize {
+		return "", tooLarge(limits.MaxSize)
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(content), ";")
+	if !accepts(limits.Accept, contentType) {
+		return "", fmt.Errorf("files of type %s are not accepted", contentType)
+	}
+	ext, ok := extensions[contentType]
+	if !ok {
+		ext = ".bin"
+	}
+
+	var thumb []byte
+	if limits.Thumbnail {
+		var err error
+		if thumb, err = thumbnail(content); err != nil {
+			return "", fmt.Errorf("cannot read the image: %w", err)
+		}
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	name := time.Now().Format("2006/01/") + hex.EncodeToString(random) + ext
+	url, err := s.Save(name, content)
+	if err != nil {
+		return "", err
+	}
+	if thumb != nil {
+		if _, err := s.Save(ThumbnailURL(name), thumb); err != nil {
+			s.Delete(url)
+			return "", err
+		}
+	}
+	return url, nil
+}
+
+// Remove deletes the file served at url from s, along with its thumbnail.
+// Empty URLs are ignored.
+func Remove(s Storage, url string) error {
+	if url == "" {
+		return nil
+	}
+	if err := s.Delete(ThumbnailURL(url)); err != nil {
+		return err
+	}
+	return s.Delete(url)
+}
+
+// ThumbnailURL returns the URL of the thumbnail of the image served at url:
+// photo.thumb.png for photo.jpg, as thumbnails are PNG images.
+func ThumbnailURL(url string) string {
+	return strings.TrimSuffix(url, path.Ext(url)) + ".thumb.png"
+}
+
+// accepts reports whether contentType matches one of accept.
+func accepts(accept []string, contentType string) bool {
+	if len(accept) == 0 {
+		return true
+	}
+	for _, pattern := range accept {
+		if pattern == contentType || strings.HasSuffix(pattern, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func tooLarge(maxSize int64) error {
+	return fmt.Errorf("files must not be larger than %s", FormatSize(maxSize))
+}
+
+// FormatSize shows a number of bytes as in 1.5 KB.
+func FormatSize(size int64) string {
+	switch {
+	case size < 1<<10:
+		return fmt.Sprintf("%d bytes", size)
+	case size < 1<<20:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"path"
+	"testing"
+)
+
+func TestSaveExtension(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		ext     string
+	}{
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", ".png"},
+		{"pdf", "%PDF-1.4\n", ".pdf"},
+		{"text", "hello", ".txt"},
+		{"html", "<!DOCTYPE html><script>alert(1)</script>", ".bin"},
+		{"svg", `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`, ".bin"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			url, err := save(NewLocal(t.TempDir(), "/uploads"), []byte(test.content), Limits{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ext := path.Ext(url); ext != test.ext {
+				t.Errorf("stored as %s, want %s", ext, test.ext)
+			}
+		})
+	}
+}